@@ -2,6 +2,8 @@ package ast
 
 import (
 	"bytes"
+	"math/big"
+	"strings"
 
 	"github.com/Urvirith/bearlang/src/token"
 )
@@ -9,6 +11,8 @@ import (
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Position // Position of the first character of the node
+	End() token.Position // Position immediately after the last character of the node
 }
 
 type Statement interface {
@@ -21,86 +25,929 @@ type Expression interface {
 	expressionNode()
 }
 
+type TypeExpr interface {
+	Node
+	typeNode()
+}
+
 // PROGRAM SECTION
 type Program struct {
 	Statements []Statement
+	Comments   []*Comment // Comments in source order, only filled when the lexer emits them
+}
+
+func (prg *Program) TokenLiteral() string {
+	if len(prg.Statements) > 0 {
+		return prg.Statements[0].TokenLiteral()
+	} else {
+		return ""
+	}
+}
+
+func (prg *Program) Pos() token.Position {
+	if len(prg.Statements) == 0 {
+		return token.Position{}
+	}
+	return prg.Statements[0].Pos()
+}
+
+func (prg *Program) End() token.Position {
+	if len(prg.Statements) == 0 {
+		return token.Position{}
+	}
+	return prg.Statements[len(prg.Statements)-1].End()
+}
+
+func (prg *Program) String() string {
+	var out bytes.Buffer
+
+	for _, s := range prg.Statements {
+		out.WriteString(s.String())
+	}
+
+	return out.String()
+}
+
+// COMMENT SECTION
+type Comment struct {
+	Token token.Token
+	Text  string
+}
+
+func (cm *Comment) TokenLiteral() string {
+	return cm.Token.Literal
+}
+
+func (cm *Comment) Pos() token.Position {
+	return cm.Token.Pos
+}
+
+func (cm *Comment) End() token.Position {
+	return cm.Token.End
+}
+
+func (cm *Comment) String() string {
+	return cm.Text
+}
+
+// LET SECTION
+type LetStatement struct {
+	Token token.Token
+	Name  *Identifier
+	Type  TypeExpr // Declared type, nil when inferred from the value
+	Value Expression
+}
+
+func (ls *LetStatement) statementNode() {
+	// Placeholder
+}
+
+func (ls *LetStatement) TokenLiteral() string {
+	return ls.Token.Literal
+}
+
+func (ls *LetStatement) Pos() token.Position {
+	return ls.Token.Pos
+}
+
+func (ls *LetStatement) End() token.Position {
+	return ls.Value.End()
+}
+
+func (ls *LetStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ls.TokenLiteral() + " ")
+	out.WriteString(ls.Name.String())
+
+	if ls.Type != nil {
+		out.WriteString(": " + ls.Type.String())
+	}
+
+	out.WriteString(" = ")
+
+	if ls.Value != nil {
+		out.WriteString(ls.Value.String())
+	}
+
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// CONST SECTION
+type ConstStatement struct {
+	Token token.Token
+	Name  *Identifier
+	Type  TypeExpr // Declared type, nil when inferred from the value
+	Value Expression
+}
+
+func (cs *ConstStatement) statementNode() {
+	// Placeholder
+}
+
+func (cs *ConstStatement) TokenLiteral() string {
+	return cs.Token.Literal
+}
+
+func (cs *ConstStatement) Pos() token.Position {
+	return cs.Token.Pos
+}
+
+func (cs *ConstStatement) End() token.Position {
+	return cs.Value.End()
+}
+
+func (cs *ConstStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(cs.TokenLiteral() + " ")
+	out.WriteString(cs.Name.String())
+
+	if cs.Type != nil {
+		out.WriteString(": " + cs.Type.String())
+	}
+
+	out.WriteString(" = ")
+
+	if cs.Value != nil {
+		out.WriteString(cs.Value.String())
+	}
+
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// BLOCK SECTION
+type BlockStatement struct {
+	Token      token.Token // The { token
+	Statements []Statement
+	Rbrace     token.Token // The closing } token
+}
+
+func (bs *BlockStatement) statementNode() {
+	// Placeholder
+}
+
+func (bs *BlockStatement) TokenLiteral() string {
+	return bs.Token.Literal
+}
+
+func (bs *BlockStatement) Pos() token.Position {
+	return bs.Token.Pos
+}
+
+func (bs *BlockStatement) End() token.Position {
+	return bs.Rbrace.End
+}
+
+func (bs *BlockStatement) String() string {
+	if len(bs.Statements) == 0 {
+		return "{}"
+	}
+
+	stmts := []string{}
+	for _, s := range bs.Statements {
+		stmts = append(stmts, s.String())
+	}
+
+	return "{ " + strings.Join(stmts, " ") + " }"
+}
+
+// FUNCTION SECTION
+type Param struct {
+	Name *Identifier
+	Type TypeExpr
+}
+
+func (pm *Param) TokenLiteral() string {
+	return pm.Name.TokenLiteral()
+}
+
+func (pm *Param) Pos() token.Position {
+	return pm.Name.Pos()
+}
+
+func (pm *Param) End() token.Position {
+	return pm.Type.End()
+}
+
+func (pm *Param) String() string {
+	return pm.Name.String() + ": " + pm.Type.String()
+}
+
+type FunctionDecl struct {
+	Token      token.Token // The fn token
+	Linkage    token.Token // The ext token, zero value when the function is internal
+	Name       *Identifier
+	Params     []*Param
+	ReturnType TypeExpr // nil when nothing is returned
+	Body       *BlockStatement
+}
+
+func (fd *FunctionDecl) statementNode() {
+	// Placeholder
+}
+
+func (fd *FunctionDecl) TokenLiteral() string {
+	return fd.Token.Literal
+}
+
+func (fd *FunctionDecl) Pos() token.Position {
+	if fd.Linkage.Pos.IsValid() {
+		return fd.Linkage.Pos
+	}
+	return fd.Token.Pos
+}
+
+func (fd *FunctionDecl) End() token.Position {
+	return fd.Body.End()
+}
+
+// Verify the function has external linkage, so it can be called from outside
+func (fd *FunctionDecl) IsExtern() bool {
+	return fd.Linkage.Type == token.EXTERN
+}
+
+func (fd *FunctionDecl) String() string {
+	var out bytes.Buffer
+
+	if fd.IsExtern() {
+		out.WriteString(fd.Linkage.Literal + " ")
+	}
+
+	params := []string{}
+	for _, p := range fd.Params {
+		params = append(params, p.String())
+	}
+
+	out.WriteString(fd.TokenLiteral() + " ")
+	out.WriteString(fd.Name.String())
+	out.WriteString("(" + strings.Join(params, ", ") + ")")
+
+	if fd.ReturnType != nil {
+		out.WriteString(" -> " + fd.ReturnType.String())
+	}
+
+	out.WriteString(" " + fd.Body.String())
+
+	return out.String()
+}
+
+// IF SECTION
+type IfStatement struct {
+	Token       token.Token // The if token, or elif when part of a chain
+	Condition   Expression
+	Consequence *BlockStatement
+	Alternative Statement // *IfStatement for elif, *BlockStatement for else, nil when neither
+}
+
+func (is *IfStatement) statementNode() {
+	// Placeholder
+}
+
+func (is *IfStatement) TokenLiteral() string {
+	return is.Token.Literal
+}
+
+func (is *IfStatement) Pos() token.Position {
+	return is.Token.Pos
+}
+
+func (is *IfStatement) End() token.Position {
+	if is.Alternative != nil {
+		return is.Alternative.End()
+	}
+	return is.Consequence.End()
+}
+
+func (is *IfStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(is.TokenLiteral() + " ")
+	out.WriteString(is.Condition.String() + " ")
+	out.WriteString(is.Consequence.String())
+
+	switch alt := is.Alternative.(type) {
+	case *IfStatement:
+		out.WriteString(" " + alt.String())
+	case *BlockStatement:
+		out.WriteString(" else " + alt.String())
+	}
+
+	return out.String()
+}
+
+// LOOP SECTION
+type LoopStatement struct {
+	Token token.Token
+	Label *Identifier // nil when the loop has no label
+	Body  *BlockStatement
+}
+
+func (ls *LoopStatement) statementNode() {
+	// Placeholder
+}
+
+func (ls *LoopStatement) TokenLiteral() string {
+	return ls.Token.Literal
+}
+
+func (ls *LoopStatement) Pos() token.Position {
+	if ls.Label != nil {
+		return ls.Label.Pos()
+	}
+	return ls.Token.Pos
+}
+
+func (ls *LoopStatement) End() token.Position {
+	return ls.Body.End()
+}
+
+func (ls *LoopStatement) String() string {
+	return labelString(ls.Label) + ls.TokenLiteral() + " " + ls.Body.String()
+}
+
+// WHILE SECTION
+type WhileStatement struct {
+	Token     token.Token
+	Label     *Identifier // nil when the loop has no label
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (ws *WhileStatement) statementNode() {
+	// Placeholder
+}
+
+func (ws *WhileStatement) TokenLiteral() string {
+	return ws.Token.Literal
+}
+
+func (ws *WhileStatement) Pos() token.Position {
+	if ws.Label != nil {
+		return ws.Label.Pos()
+	}
+	return ws.Token.Pos
+}
+
+func (ws *WhileStatement) End() token.Position {
+	return ws.Body.End()
+}
+
+func (ws *WhileStatement) String() string {
+	return labelString(ws.Label) + ws.TokenLiteral() + " " + ws.Condition.String() + " " + ws.Body.String()
+}
+
+// FOR SECTION
+type ForStatement struct {
+	Token    token.Token
+	Label    *Identifier // nil when the loop has no label
+	Var      *Identifier
+	VarType  TypeExpr // nil when inferred from the iterable
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (fs *ForStatement) statementNode() {
+	// Placeholder
+}
+
+func (fs *ForStatement) TokenLiteral() string {
+	return fs.Token.Literal
+}
+
+func (fs *ForStatement) Pos() token.Position {
+	if fs.Label != nil {
+		return fs.Label.Pos()
+	}
+	return fs.Token.Pos
+}
+
+func (fs *ForStatement) End() token.Position {
+	return fs.Body.End()
+}
+
+func (fs *ForStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(labelString(fs.Label))
+	out.WriteString(fs.TokenLiteral() + " ")
+	out.WriteString(fs.Var.String())
+
+	if fs.VarType != nil {
+		out.WriteString(": " + fs.VarType.String())
+	}
+
+	out.WriteString(" in " + fs.Iterable.String() + " ")
+	out.WriteString(fs.Body.String())
+
+	return out.String()
+}
+
+// BREAK SECTION
+type BreakStatement struct {
+	Token token.Token
+	Label *Identifier // nil to break the innermost loop
+}
+
+func (bs *BreakStatement) statementNode() {
+	// Placeholder
+}
+
+func (bs *BreakStatement) TokenLiteral() string {
+	return bs.Token.Literal
+}
+
+func (bs *BreakStatement) Pos() token.Position {
+	return bs.Token.Pos
+}
+
+func (bs *BreakStatement) End() token.Position {
+	if bs.Label != nil {
+		return bs.Label.End()
+	}
+	return bs.Token.End
+}
+
+func (bs *BreakStatement) String() string {
+	if bs.Label != nil {
+		return bs.TokenLiteral() + " " + bs.Label.String() + ";"
+	}
+	return bs.TokenLiteral() + ";"
+}
+
+// CONTINUE SECTION
+type ContinueStatement struct {
+	Token token.Token
+	Label *Identifier // nil to continue the innermost loop
+}
+
+func (cs *ContinueStatement) statementNode() {
+	// Placeholder
+}
+
+func (cs *ContinueStatement) TokenLiteral() string {
+	return cs.Token.Literal
+}
+
+func (cs *ContinueStatement) Pos() token.Position {
+	return cs.Token.Pos
+}
+
+func (cs *ContinueStatement) End() token.Position {
+	if cs.Label != nil {
+		return cs.Label.End()
+	}
+	return cs.Token.End
+}
+
+func (cs *ContinueStatement) String() string {
+	if cs.Label != nil {
+		return cs.TokenLiteral() + " " + cs.Label.String() + ";"
+	}
+	return cs.TokenLiteral() + ";"
+}
+
+// Format a loop label followed by its colon, empty when there is no label
+func labelString(label *Identifier) string {
+	if label == nil {
+		return ""
+	}
+	return label.String() + ": "
+}
+
+// ATTRIBUTE SECTION
+type Attribute struct {
+	Token  token.Token // The @ token
+	Name   *Identifier
+	Args   []Expression // Values in brackets, @align(4)
+	Rparen token.Token  // The closing ) token, zero value when there are no brackets
+}
+
+func (at *Attribute) TokenLiteral() string {
+	return at.Token.Literal
+}
+
+func (at *Attribute) Pos() token.Position {
+	return at.Token.Pos
+}
+
+func (at *Attribute) End() token.Position {
+	if at.Rparen.Pos.IsValid() {
+		return at.Rparen.End
+	}
+	return at.Name.End()
+}
+
+func (at *Attribute) String() string {
+	if len(at.Args) == 0 {
+		return "@" + at.Name.String()
+	}
+
+	args := []string{}
+	for _, a := range at.Args {
+		args = append(args, a.String())
+	}
+
+	return "@" + at.Name.String() + "(" + strings.Join(args, ", ") + ")"
+}
+
+// Format attributes followed by a space, empty when there are none
+func attributeString(attrs []*Attribute) string {
+	var out bytes.Buffer
+
+	for _, a := range attrs {
+		out.WriteString(a.String() + " ")
+	}
+
+	return out.String()
+}
+
+// FIELD SECTION
+type Field struct {
+	Name *Identifier
+	Type TypeExpr
+}
+
+func (fl *Field) TokenLiteral() string {
+	return fl.Name.TokenLiteral()
+}
+
+func (fl *Field) Pos() token.Position {
+	return fl.Name.Pos()
+}
+
+func (fl *Field) End() token.Position {
+	return fl.Type.End()
+}
+
+func (fl *Field) String() string {
+	return fl.Name.String() + ": " + fl.Type.String()
+}
+
+// Format the fields of a struct or union as a braced list
+func fieldString(fields []*Field) string {
+	if len(fields) == 0 {
+		return "{}"
+	}
+
+	list := []string{}
+	for _, f := range fields {
+		list = append(list, f.String())
+	}
+
+	return "{ " + strings.Join(list, ", ") + " }"
+}
+
+// STRUCT SECTION
+type StructDecl struct {
+	Token      token.Token // The struct token
+	Attributes []*Attribute
+	Name       *Identifier
+	Fields     []*Field
+	Rbrace     token.Token // The closing } token
+}
+
+func (sd *StructDecl) statementNode() {
+	// Placeholder
+}
+
+func (sd *StructDecl) TokenLiteral() string {
+	return sd.Token.Literal
+}
+
+func (sd *StructDecl) Pos() token.Position {
+	if len(sd.Attributes) != 0 {
+		return sd.Attributes[0].Pos()
+	}
+	return sd.Token.Pos
+}
+
+func (sd *StructDecl) End() token.Position {
+	return sd.Rbrace.End
+}
+
+func (sd *StructDecl) String() string {
+	return attributeString(sd.Attributes) + sd.TokenLiteral() + " " + sd.Name.String() + " " + fieldString(sd.Fields)
+}
+
+// UNION SECTION
+type UnionDecl struct {
+	Token      token.Token // The union token
+	Attributes []*Attribute
+	Name       *Identifier
+	Fields     []*Field    // Every field starts at offset 0
+	Rbrace     token.Token // The closing } token
+}
+
+func (ud *UnionDecl) statementNode() {
+	// Placeholder
+}
+
+func (ud *UnionDecl) TokenLiteral() string {
+	return ud.Token.Literal
+}
+
+func (ud *UnionDecl) Pos() token.Position {
+	if len(ud.Attributes) != 0 {
+		return ud.Attributes[0].Pos()
+	}
+	return ud.Token.Pos
+}
+
+func (ud *UnionDecl) End() token.Position {
+	return ud.Rbrace.End
+}
+
+func (ud *UnionDecl) String() string {
+	return attributeString(ud.Attributes) + ud.TokenLiteral() + " " + ud.Name.String() + " " + fieldString(ud.Fields)
+}
+
+// ENUM SECTION
+type EnumVariant struct {
+	Name  *Identifier
+	Value Expression // Explicit discriminant, nil to follow on from the previous variant
+}
+
+func (ev *EnumVariant) TokenLiteral() string {
+	return ev.Name.TokenLiteral()
+}
+
+func (ev *EnumVariant) Pos() token.Position {
+	return ev.Name.Pos()
+}
+
+func (ev *EnumVariant) End() token.Position {
+	if ev.Value != nil {
+		return ev.Value.End()
+	}
+	return ev.Name.End()
+}
+
+func (ev *EnumVariant) String() string {
+	if ev.Value == nil {
+		return ev.Name.String()
+	}
+	return ev.Name.String() + " = " + ev.Value.String()
+}
+
+type EnumDecl struct {
+	Token      token.Token // The enum token
+	Attributes []*Attribute
+	Name       *Identifier
+	Backing    TypeExpr // Integer type holding the discriminant, nil for the default u32
+	Variants   []*EnumVariant
+	Rbrace     token.Token // The closing } token
+}
+
+func (ed *EnumDecl) statementNode() {
+	// Placeholder
+}
+
+func (ed *EnumDecl) TokenLiteral() string {
+	return ed.Token.Literal
+}
+
+func (ed *EnumDecl) Pos() token.Position {
+	if len(ed.Attributes) != 0 {
+		return ed.Attributes[0].Pos()
+	}
+	return ed.Token.Pos
+}
+
+func (ed *EnumDecl) End() token.Position {
+	return ed.Rbrace.End
+}
+
+func (ed *EnumDecl) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(attributeString(ed.Attributes))
+	out.WriteString(ed.TokenLiteral() + " " + ed.Name.String())
+
+	if ed.Backing != nil {
+		out.WriteString(": " + ed.Backing.String())
+	}
+
+	if len(ed.Variants) == 0 {
+		out.WriteString(" {}")
+		return out.String()
+	}
+
+	variants := []string{}
+	for _, v := range ed.Variants {
+		variants = append(variants, v.String())
+	}
+
+	out.WriteString(" { " + strings.Join(variants, ", ") + " }")
+
+	return out.String()
+}
+
+// RETURN SECTION
+type ReturnStatement struct {
+	Token token.Token
+	Value Expression
+}
+
+func (rs *ReturnStatement) statementNode() {
+	// Placeholder
+}
+
+func (rs *ReturnStatement) TokenLiteral() string {
+	return rs.Token.Literal
+}
+
+func (rs *ReturnStatement) Pos() token.Position {
+	return rs.Token.Pos
+}
+
+func (rs *ReturnStatement) End() token.Position {
+	if rs.Value == nil {
+		return rs.Token.End
+	}
+	return rs.Value.End()
+}
+
+func (rs *ReturnStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(rs.TokenLiteral())
+
+	if rs.Value != nil {
+		out.WriteString(" " + rs.Value.String())
+	}
+
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// ASSIGN SECTION
+type AssignStatement struct {
+	Token  token.Token // The operator, = += |= ++ etc
+	Target Expression  // Place being written
+	Value  Expression  // nil for ++ and --
+}
+
+func (as *AssignStatement) statementNode() {
+	// Placeholder
+}
+
+func (as *AssignStatement) TokenLiteral() string {
+	return as.Token.Literal
+}
+
+func (as *AssignStatement) Pos() token.Position {
+	return as.Target.Pos()
+}
+
+func (as *AssignStatement) End() token.Position {
+	if as.Value != nil {
+		return as.Value.End()
+	}
+	return as.Token.End
+}
+
+func (as *AssignStatement) String() string {
+	if as.Value == nil {
+		return as.Target.String() + as.TokenLiteral() + ";"
+	}
+	return as.Target.String() + " " + as.TokenLiteral() + " " + as.Value.String() + ";"
+}
+
+// PRIMITIVE TYPE SECTION
+type PrimitiveType struct {
+	Token token.Token
+	Name  string // i8..i128, u8..u128, f32, f64 or bool
+}
+
+func (pt *PrimitiveType) typeNode() {
+	// Placeholder
+}
+
+func (pt *PrimitiveType) TokenLiteral() string {
+	return pt.Token.Literal
+}
+
+func (pt *PrimitiveType) Pos() token.Position {
+	return pt.Token.Pos
+}
+
+func (pt *PrimitiveType) End() token.Position {
+	return pt.Token.End
+}
+
+func (pt *PrimitiveType) String() string {
+	return pt.Name
+}
+
+// POINTER TYPE SECTION
+type PointerType struct {
+	Token token.Token // The * token
+	Elem  TypeExpr
 }
 
-func (prg *Program) TokenLiteral() string {
-	if len(prg.Statements) > 0 {
-		return prg.Statements[0].TokenLiteral()
-	} else {
-		return ""
-	}
+func (pt *PointerType) typeNode() {
+	// Placeholder
 }
 
-func (prg *Program) String() string {
-	var out bytes.Buffer
+func (pt *PointerType) TokenLiteral() string {
+	return pt.Token.Literal
+}
 
-	for _, s := range prg.Statements {
-		out.WriteString(s.String())
-	}
+func (pt *PointerType) Pos() token.Position {
+	return pt.Elem.Pos()
+}
 
-	return out.String()
+func (pt *PointerType) End() token.Position {
+	return pt.Token.End
 }
 
-// LET SECTION
-type LetStatement struct {
-	Token token.Token
-	Name  *Identifier
-	Value Expression
+func (pt *PointerType) String() string {
+	return pt.Elem.String() + "*"
 }
 
-func (ls *LetStatement) statementNode() {
+// VOLATILE TYPE SECTION
+type VolatileType struct {
+	Token token.Token // The vol token
+	Elem  TypeExpr
+}
+
+func (vt *VolatileType) typeNode() {
 	// Placeholder
 }
 
-func (ls *LetStatement) TokenLiteral() string {
-	return ls.Token.Literal
+func (vt *VolatileType) TokenLiteral() string {
+	return vt.Token.Literal
 }
 
-func (ls *LetStatement) String() string {
-	var out bytes.Buffer
+func (vt *VolatileType) Pos() token.Position {
+	return vt.Token.Pos
+}
 
-	out.WriteString(ls.TokenLiteral() + " ")
-	out.WriteString(ls.Name.String())
-	out.WriteString(" = ")
+func (vt *VolatileType) End() token.Position {
+	return vt.Elem.End()
+}
 
-	if ls.Value != nil {
-		out.WriteString(ls.Value.String())
-	}
+func (vt *VolatileType) String() string {
+	return "vol " + vt.Elem.String()
+}
 
-	out.WriteString(";")
+// ARRAY TYPE SECTION
+type ArrayType struct {
+	Token token.Token // The [ token
+	Len   Expression  // Fixed length, must be known at compile time
+	Elem  TypeExpr
+}
 
-	return out.String()
+func (at *ArrayType) typeNode() {
+	// Placeholder
 }
 
-// RETURN SECTION
-type ReturnStatement struct {
-	Token token.Token
-	Value Expression
+func (at *ArrayType) TokenLiteral() string {
+	return at.Token.Literal
 }
 
-func (rs *ReturnStatement) statementNode() {
-	// Placeholder
+func (at *ArrayType) Pos() token.Position {
+	return at.Token.Pos
 }
 
-func (rs *ReturnStatement) TokenLiteral() string {
-	return rs.Token.Literal
+func (at *ArrayType) End() token.Position {
+	return at.Elem.End()
 }
 
-func (rs *ReturnStatement) String() string {
-	var out bytes.Buffer
+func (at *ArrayType) String() string {
+	return "[" + at.Len.String() + "]" + at.Elem.String()
+}
 
-	out.WriteString(rs.TokenLiteral() + " ")
+// NAMED TYPE SECTION
+type NamedType struct {
+	Token token.Token
+	Name  string // Struct, enum or union name
+}
 
-	if rs.Value != nil {
-		out.WriteString(rs.Value.String())
-	}
+func (nt *NamedType) typeNode() {
+	// Placeholder
+}
 
-	out.WriteString(";")
+func (nt *NamedType) TokenLiteral() string {
+	return nt.Token.Literal
+}
 
-	return out.String()
+func (nt *NamedType) Pos() token.Position {
+	return nt.Token.Pos
+}
+
+func (nt *NamedType) End() token.Position {
+	return nt.Token.End
+}
+
+func (nt *NamedType) String() string {
+	return nt.Name
 }
 
 // IDENTIFIER SECTION
@@ -117,6 +964,14 @@ func (ind *Identifier) TokenLiteral() string {
 	return ind.Token.Literal
 }
 
+func (ind *Identifier) Pos() token.Position {
+	return ind.Token.Pos
+}
+
+func (ind *Identifier) End() token.Position {
+	return ind.Token.End
+}
+
 func (ind *Identifier) String() string {
 	return ind.Value
 }
@@ -135,6 +990,14 @@ func (es *ExpressionStatment) TokenLiteral() string {
 	return es.Token.Literal
 }
 
+func (es *ExpressionStatment) Pos() token.Position {
+	return es.Expression.Pos()
+}
+
+func (es *ExpressionStatment) End() token.Position {
+	return es.Expression.End()
+}
+
 func (es *ExpressionStatment) String() string {
 	if es.Expression != nil {
 		return es.Expression.String()
@@ -145,8 +1008,9 @@ func (es *ExpressionStatment) String() string {
 
 // INTEGER LITERAL SECTION
 type IntegerLiteral struct {
-	Token token.Token
-	Value int64
+	Token  token.Token
+	Value  *big.Int // Arbitrary precision so every u128 and i128 value fits
+	Suffix string   // Type suffix such as u8, empty when not given
 }
 
 func (il *IntegerLiteral) expressionNode() {
@@ -157,10 +1021,136 @@ func (il *IntegerLiteral) TokenLiteral() string {
 	return il.Token.Literal
 }
 
+func (il *IntegerLiteral) Pos() token.Position {
+	return il.Token.Pos
+}
+
+func (il *IntegerLiteral) End() token.Position {
+	return il.Token.End
+}
+
 func (il *IntegerLiteral) String() string {
 	return il.Token.Literal
 }
 
+// FLOAT LITERAL SECTION
+type FloatLiteral struct {
+	Token  token.Token
+	Value  float64
+	Suffix string // Type suffix f32 or f64, empty when not given
+}
+
+func (fl *FloatLiteral) expressionNode() {
+	// Placeholder
+}
+
+func (fl *FloatLiteral) TokenLiteral() string {
+	return fl.Token.Literal
+}
+
+func (fl *FloatLiteral) Pos() token.Position {
+	return fl.Token.Pos
+}
+
+func (fl *FloatLiteral) End() token.Position {
+	return fl.Token.End
+}
+
+func (fl *FloatLiteral) String() string {
+	return fl.Token.Literal
+}
+
+// CHAR LITERAL SECTION
+type CharLiteral struct {
+	Token token.Token
+	Value byte // Typed as u8
+}
+
+func (cl *CharLiteral) expressionNode() {
+	// Placeholder
+}
+
+func (cl *CharLiteral) TokenLiteral() string {
+	return cl.Token.Literal
+}
+
+func (cl *CharLiteral) Pos() token.Position {
+	return cl.Token.Pos
+}
+
+func (cl *CharLiteral) End() token.Position {
+	return cl.Token.End
+}
+
+func (cl *CharLiteral) String() string {
+	return cl.Token.Literal
+}
+
+// STRING LITERAL SECTION
+type StringLiteral struct {
+	Token token.Token
+	Value []byte // Escapes decoded, typed as a fixed [N]u8 array with N = len(Value), no terminator is added
+}
+
+func (sl *StringLiteral) expressionNode() {
+	// Placeholder
+}
+
+func (sl *StringLiteral) TokenLiteral() string {
+	return sl.Token.Literal
+}
+
+func (sl *StringLiteral) Pos() token.Position {
+	return sl.Token.Pos
+}
+
+func (sl *StringLiteral) End() token.Position {
+	return sl.Token.End
+}
+
+func (sl *StringLiteral) String() string {
+	return sl.Token.Literal
+}
+
+// GROUP SECTION
+type GroupExpression struct {
+	Lparen     token.Token // The ( token
+	Expression Expression
+	Rparen     token.Token // The ) token
+}
+
+func (ge *GroupExpression) expressionNode() {
+	// Placeholder
+}
+
+func (ge *GroupExpression) TokenLiteral() string {
+	return ge.Lparen.Literal
+}
+
+func (ge *GroupExpression) Pos() token.Position {
+	return ge.Lparen.Pos
+}
+
+func (ge *GroupExpression) End() token.Position {
+	return ge.Rparen.End
+}
+
+// Operators already print their own brackets
+func (ge *GroupExpression) String() string {
+	return ge.Expression.String()
+}
+
+// Expression inside any brackets around it
+func Unparen(expr Expression) Expression {
+	for {
+		group, ok := expr.(*GroupExpression)
+		if !ok {
+			return expr
+		}
+		expr = group.Expression
+	}
+}
+
 // PREFIX LITERAL SECTION
 type PrefixExpression struct {
 	Token    token.Token
@@ -176,6 +1166,14 @@ func (pe *PrefixExpression) TokenLiteral() string {
 	return pe.Token.Literal
 }
 
+func (pe *PrefixExpression) Pos() token.Position {
+	return pe.Token.Pos
+}
+
+func (pe *PrefixExpression) End() token.Position {
+	return pe.Right.End()
+}
+
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 
@@ -187,6 +1185,146 @@ func (pe *PrefixExpression) String() string {
 	return out.String()
 }
 
+// DEREFERENCE SECTION
+type DerefExpression struct {
+	Token   token.Token // The * token
+	Operand Expression  // Must be a pointer
+}
+
+func (de *DerefExpression) expressionNode() {
+	// Placeholder
+}
+
+func (de *DerefExpression) TokenLiteral() string {
+	return de.Token.Literal
+}
+
+func (de *DerefExpression) Pos() token.Position {
+	return de.Token.Pos
+}
+
+func (de *DerefExpression) End() token.Position {
+	return de.Operand.End()
+}
+
+func (de *DerefExpression) String() string {
+	return "(*" + de.Operand.String() + ")"
+}
+
+// ADDRESS OF SECTION
+type AddressOf struct {
+	Token   token.Token // The & token
+	Operand Expression  // Must be a place in memory
+}
+
+func (ao *AddressOf) expressionNode() {
+	// Placeholder
+}
+
+func (ao *AddressOf) TokenLiteral() string {
+	return ao.Token.Literal
+}
+
+func (ao *AddressOf) Pos() token.Position {
+	return ao.Token.Pos
+}
+
+func (ao *AddressOf) End() token.Position {
+	return ao.Operand.End()
+}
+
+func (ao *AddressOf) String() string {
+	return "(&" + ao.Operand.String() + ")"
+}
+
+// CALL SECTION
+type CallExpression struct {
+	Token     token.Token // The ( token
+	Function  Expression  // Identifier or expression naming the function
+	Arguments []Expression
+	Rparen    token.Token // The closing ) token
+}
+
+func (ce *CallExpression) expressionNode() {
+	// Placeholder
+}
+
+func (ce *CallExpression) TokenLiteral() string {
+	return ce.Token.Literal
+}
+
+func (ce *CallExpression) Pos() token.Position {
+	return ce.Function.Pos()
+}
+
+func (ce *CallExpression) End() token.Position {
+	return ce.Rparen.End
+}
+
+func (ce *CallExpression) String() string {
+	args := []string{}
+	for _, a := range ce.Arguments {
+		args = append(args, a.String())
+	}
+
+	return ce.Function.String() + "(" + strings.Join(args, ", ") + ")"
+}
+
+// INDEX SECTION
+type IndexExpression struct {
+	Token  token.Token // The [ token
+	Left   Expression  // Array being indexed
+	Index  Expression
+	Rbrack token.Token // The closing ] token
+}
+
+func (ie *IndexExpression) expressionNode() {
+	// Placeholder
+}
+
+func (ie *IndexExpression) TokenLiteral() string {
+	return ie.Token.Literal
+}
+
+func (ie *IndexExpression) Pos() token.Position {
+	return ie.Left.Pos()
+}
+
+func (ie *IndexExpression) End() token.Position {
+	return ie.Rbrack.End
+}
+
+func (ie *IndexExpression) String() string {
+	return "(" + ie.Left.String() + "[" + ie.Index.String() + "])"
+}
+
+// FIELD ACCESS SECTION
+type FieldExpression struct {
+	Token token.Token // The . token
+	Left  Expression  // Struct or union holding the field
+	Field *Identifier
+}
+
+func (fe *FieldExpression) expressionNode() {
+	// Placeholder
+}
+
+func (fe *FieldExpression) TokenLiteral() string {
+	return fe.Token.Literal
+}
+
+func (fe *FieldExpression) Pos() token.Position {
+	return fe.Left.Pos()
+}
+
+func (fe *FieldExpression) End() token.Position {
+	return fe.Field.End()
+}
+
+func (fe *FieldExpression) String() string {
+	return fe.Left.String() + "." + fe.Field.String()
+}
+
 // INFIX LITERAL SECTION
 type InfixExpression struct {
 	Token    token.Token
@@ -203,6 +1341,14 @@ func (oe *InfixExpression) TokenLiteral() string {
 	return oe.Token.Literal
 }
 
+func (oe *InfixExpression) Pos() token.Position {
+	return oe.Left.Pos()
+}
+
+func (oe *InfixExpression) End() token.Position {
+	return oe.Right.End()
+}
+
 func (oe *InfixExpression) String() string {
 	var out bytes.Buffer
 
@@ -215,6 +1361,99 @@ func (oe *InfixExpression) String() string {
 	return out.String()
 }
 
+// RANGE SECTION
+type RangeExpression struct {
+	Token     token.Token // The .. or ..= token
+	Low       Expression
+	High      Expression
+	Inclusive bool // ..= includes the end
+}
+
+func (re *RangeExpression) expressionNode() {
+	// Placeholder
+}
+
+func (re *RangeExpression) TokenLiteral() string {
+	return re.Token.Literal
+}
+
+func (re *RangeExpression) Pos() token.Position {
+	return re.Low.Pos()
+}
+
+func (re *RangeExpression) End() token.Position {
+	return re.High.End()
+}
+
+func (re *RangeExpression) String() string {
+	return re.Low.String() + re.Token.Literal + re.High.String()
+}
+
+// MATCH SECTION
+type MatchArm struct {
+	Token    token.Token  // First token of the arm
+	Patterns []Expression // Alternatives separated by |, empty for the default arm
+	Default  bool
+	Body     Statement // *BlockStatement, or *ExpressionStatment for a single expression
+}
+
+func (ma *MatchArm) TokenLiteral() string {
+	return ma.Token.Literal
+}
+
+func (ma *MatchArm) Pos() token.Position {
+	return ma.Token.Pos
+}
+
+func (ma *MatchArm) End() token.Position {
+	return ma.Body.End()
+}
+
+func (ma *MatchArm) String() string {
+	patterns := []string{}
+	for _, p := range ma.Patterns {
+		patterns = append(patterns, p.String())
+	}
+
+	if ma.Default {
+		patterns = append(patterns, "default")
+	}
+
+	return strings.Join(patterns, " | ") + " => " + ma.Body.String()
+}
+
+type MatchExpression struct {
+	Token   token.Token // The match token
+	Subject Expression
+	Arms    []*MatchArm
+	Rbrace  token.Token // The closing } token
+}
+
+func (me *MatchExpression) expressionNode() {
+	// Placeholder
+}
+
+func (me *MatchExpression) TokenLiteral() string {
+	return me.Token.Literal
+}
+
+func (me *MatchExpression) Pos() token.Position {
+	return me.Token.Pos
+}
+
+func (me *MatchExpression) End() token.Position {
+	return me.Rbrace.End
+}
+
+func (me *MatchExpression) String() string {
+	arms := []string{}
+	for _, a := range me.Arms {
+		arms = append(arms, a.String())
+	}
+
+	return me.TokenLiteral() + " " + me.Subject.String() + " { " + strings.Join(arms, ", ") + " }"
+}
+
 // Boolean
 type Boolean struct {
 	Token token.Token
@@ -229,6 +1468,14 @@ func (bo *Boolean) TokenLiteral() string {
 	return bo.Token.Literal
 }
 
+func (bo *Boolean) Pos() token.Position {
+	return bo.Token.Pos
+}
+
+func (bo *Boolean) End() token.Position {
+	return bo.Token.End
+}
+
 func (bo *Boolean) String() string {
 	return bo.Token.Literal
 }
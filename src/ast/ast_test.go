@@ -1,6 +1,10 @@
 package ast
 
 import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/Urvirith/bearlang/src/token"
@@ -36,3 +40,299 @@ func TestString(t *testing.T) {
 		t.Errorf("program.String() wrong. got=%q", program.String())
 	}
 }
+
+func TestLetStringWithType(t *testing.T) {
+	stmt := &LetStatement{
+		Token: token.Token{Type: token.LET, Literal: "let"},
+		Name: &Identifier{
+			Token: token.Token{Type: token.IDENTIFIER, Literal: "x"},
+			Value: "x",
+		},
+		Type: &PrimitiveType{
+			Token: token.Token{Type: token.U16, Literal: "u16"},
+			Name:  "u16",
+		},
+		Value: &Identifier{
+			Token: token.Token{Type: token.IDENTIFIER, Literal: "y"},
+			Value: "y",
+		},
+	}
+
+	if stmt.String() != "let x: u16 = y;" {
+		t.Errorf("stmt.String() wrong. got=%q", stmt.String())
+	}
+}
+
+func ident(name string) *Identifier {
+	return &Identifier{Token: token.Token{Type: token.IDENTIFIER, Literal: name}, Value: name}
+}
+
+func integer(val int64) *IntegerLiteral {
+	return &IntegerLiteral{Token: token.Token{Type: token.INT, Literal: big.NewInt(val).String()}, Value: big.NewInt(val)}
+}
+
+// Tree holding every node type, with every optional child set
+func everyNode() *Program {
+	u8 := &PrimitiveType{Name: "u8"}
+
+	return &Program{
+		Statements: []Statement{
+			&LetStatement{Name: ident("a"), Type: u8, Value: &FloatLiteral{Value: 1.5}},
+			&ConstStatement{Name: ident("b"), Type: &VolatileType{Elem: &PointerType{Elem: u8}}, Value: &CharLiteral{Value: 'c'}},
+			&StructDecl{
+				Attributes: []*Attribute{{Name: ident("align"), Args: []Expression{integer(4)}}},
+				Name:       ident("S"),
+				Fields:     []*Field{{Name: ident("f"), Type: &ArrayType{Len: integer(2), Elem: &NamedType{Name: "T"}}}},
+			},
+			&UnionDecl{Name: ident("U"), Fields: []*Field{{Name: ident("g"), Type: u8}}},
+			&EnumDecl{Name: ident("E"), Backing: u8, Variants: []*EnumVariant{{Name: ident("V"), Value: integer(1)}}},
+			&FunctionDecl{
+				Name:       ident("f"),
+				Params:     []*Param{{Name: ident("p"), Type: u8}},
+				ReturnType: u8,
+				Body: &BlockStatement{Statements: []Statement{
+					&IfStatement{
+						Condition:   &Boolean{Value: true},
+						Consequence: &BlockStatement{Statements: []Statement{&ReturnStatement{}}},
+						Alternative: &BlockStatement{Statements: []Statement{&ReturnStatement{Value: &StringLiteral{Value: []byte("s")}}}},
+					},
+					&LoopStatement{Label: ident("outer"), Body: &BlockStatement{Statements: []Statement{
+						&BreakStatement{Label: ident("outer")},
+						&ContinueStatement{Label: ident("outer")},
+					}}},
+					&WhileStatement{Label: ident("w"), Condition: &PrefixExpression{Operator: "!", Right: &GroupExpression{Expression: ident("x")}}, Body: &BlockStatement{}},
+					&ForStatement{
+						Label:    ident("l"),
+						Var:      ident("i"),
+						VarType:  u8,
+						Iterable: &RangeExpression{Low: integer(0), High: integer(9)},
+						Body:     &BlockStatement{},
+					},
+					&AssignStatement{Target: &DerefExpression{Operand: ident("p")}, Value: &AddressOf{Operand: ident("q")}},
+					&ExpressionStatment{Expression: &CallExpression{
+						Function:  ident("g"),
+						Arguments: []Expression{&IndexExpression{Left: ident("r"), Index: integer(0)}, &FieldExpression{Left: ident("s"), Field: ident("t")}},
+					}},
+					&ExpressionStatment{Expression: &MatchExpression{
+						Subject: &InfixExpression{Left: ident("x"), Operator: "+", Right: integer(1)},
+						Arms:    []*MatchArm{{Patterns: []Expression{integer(0)}, Body: &ExpressionStatment{Expression: integer(1)}}},
+					}},
+				}},
+			},
+		},
+		Comments: []*Comment{{Text: "note"}},
+	}
+}
+
+func TestInspect(t *testing.T) {
+	expected := []string{
+		"*ast.Program",
+		"*ast.LetStatement", "*ast.Identifier", "*ast.PrimitiveType", "*ast.FloatLiteral",
+		"*ast.ConstStatement", "*ast.Identifier", "*ast.VolatileType", "*ast.PointerType", "*ast.PrimitiveType", "*ast.CharLiteral",
+		"*ast.StructDecl", "*ast.Attribute", "*ast.Identifier", "*ast.IntegerLiteral", "*ast.Identifier",
+		"*ast.Field", "*ast.Identifier", "*ast.ArrayType", "*ast.IntegerLiteral", "*ast.NamedType",
+		"*ast.UnionDecl", "*ast.Identifier", "*ast.Field", "*ast.Identifier", "*ast.PrimitiveType",
+		"*ast.EnumDecl", "*ast.Identifier", "*ast.PrimitiveType", "*ast.EnumVariant", "*ast.Identifier", "*ast.IntegerLiteral",
+		"*ast.FunctionDecl", "*ast.Identifier", "*ast.Param", "*ast.Identifier", "*ast.PrimitiveType", "*ast.PrimitiveType", "*ast.BlockStatement",
+		"*ast.IfStatement", "*ast.Boolean", "*ast.BlockStatement", "*ast.ReturnStatement", "*ast.BlockStatement", "*ast.ReturnStatement", "*ast.StringLiteral",
+		"*ast.LoopStatement", "*ast.Identifier", "*ast.BlockStatement", "*ast.BreakStatement", "*ast.Identifier", "*ast.ContinueStatement", "*ast.Identifier",
+		"*ast.WhileStatement", "*ast.Identifier", "*ast.PrefixExpression", "*ast.GroupExpression", "*ast.Identifier", "*ast.BlockStatement",
+		"*ast.ForStatement", "*ast.Identifier", "*ast.Identifier", "*ast.PrimitiveType", "*ast.RangeExpression", "*ast.IntegerLiteral", "*ast.IntegerLiteral", "*ast.BlockStatement",
+		"*ast.AssignStatement", "*ast.DerefExpression", "*ast.Identifier", "*ast.AddressOf", "*ast.Identifier",
+		"*ast.ExpressionStatment", "*ast.CallExpression", "*ast.Identifier", "*ast.IndexExpression", "*ast.Identifier", "*ast.IntegerLiteral",
+		"*ast.FieldExpression", "*ast.Identifier", "*ast.Identifier",
+		"*ast.ExpressionStatment", "*ast.MatchExpression", "*ast.InfixExpression", "*ast.Identifier", "*ast.IntegerLiteral",
+		"*ast.MatchArm", "*ast.IntegerLiteral", "*ast.ExpressionStatment", "*ast.IntegerLiteral",
+		"*ast.Comment",
+	}
+
+	visited := []string{}
+	depth := 0
+
+	Inspect(everyNode(), func(node Node) bool {
+		if node == nil {
+			depth--
+			return false
+		}
+
+		visited = append(visited, fmt.Sprintf("%T", node))
+		depth++
+		return true
+	})
+
+	if depth != 0 {
+		t.Errorf("Inspect did not call f(nil) once per node, depth=%d", depth)
+	}
+
+	if len(visited) != len(expected) {
+		t.Fatalf("visited %d nodes, expected %d. got=%v", len(visited), len(expected), visited)
+	}
+
+	for i, typ := range expected {
+		if visited[i] != typ {
+			t.Errorf("visited[%d] - expected=%s, got=%s", i, typ, visited[i])
+		}
+	}
+}
+
+func TestInspectSkipsChildren(t *testing.T) {
+	count := 0
+
+	Inspect(everyNode(), func(node Node) bool {
+		if node == nil {
+			return false
+		}
+
+		count++
+		_, isFunc := node.(*FunctionDecl)
+		return !isFunc
+	})
+
+	// Everything inside the function is skipped, the comment after it is not
+	if count != 34 {
+		t.Errorf("expected 34 nodes outside the function. got=%d", count)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	prg := everyNode()
+
+	// Rename every x and fold 1 + 1
+	out := Rewrite(prg, func(node Node) Node {
+		switch n := node.(type) {
+		case *Identifier:
+			if n.Value == "x" {
+				return ident("y")
+			}
+		case *InfixExpression:
+			return integer(2)
+		}
+		return node
+	})
+
+	if out != Node(prg) {
+		t.Fatalf("Rewrite replaced the program. got=%T", out)
+	}
+
+	Inspect(prg, func(node Node) bool {
+		switch n := node.(type) {
+		case *Identifier:
+			if n.Value == "x" {
+				t.Errorf("identifier x was not rewritten")
+			}
+		case *InfixExpression:
+			t.Errorf("infix expression %s was not rewritten", n)
+		}
+		return true
+	})
+
+	body := prg.Statements[5].(*FunctionDecl).Body.Statements
+	match := body[len(body)-1].(*ExpressionStatment).Expression.(*MatchExpression)
+
+	if match.Subject.String() != "2" {
+		t.Errorf("match subject not rewritten. got=%s", match.Subject)
+	}
+}
+
+func TestRewriteMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("replacing an expression with a statement did not panic")
+		}
+	}()
+
+	Rewrite(everyNode(), func(node Node) Node {
+		if _, ok := node.(*FloatLiteral); ok {
+			return &BlockStatement{}
+		}
+		return node
+	})
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	prg := everyNode()
+	prg.Statements = append(prg.Statements, &LetStatement{Name: ident("raw"), Value: &StringLiteral{Value: []byte{0xff, 'a'}}})
+
+	data, err := EncodeJSON(prg)
+	if err != nil {
+		t.Fatalf("EncodeJSON: %s", err)
+	}
+
+	decoded, err := DecodeJSON(data)
+	if err != nil {
+		t.Fatalf("DecodeJSON: %s", err)
+	}
+
+	if decoded.String() != prg.String() {
+		t.Errorf("decoded program wrong. expected=%q, got=%q", prg.String(), decoded.String())
+	}
+
+	again, err := EncodeJSON(decoded)
+	if err != nil || string(again) != string(data) {
+		t.Errorf("decoded program does not encode to the same JSON, err=%v", err)
+	}
+
+	raw := decoded.Statements[len(decoded.Statements)-1].(*LetStatement).Value.(*StringLiteral)
+	if string(raw.Value) != "\xffa" {
+		t.Errorf("string bytes not kept. got=%q", raw.Value)
+	}
+}
+
+func TestJSONKinds(t *testing.T) {
+	Inspect(everyNode(), func(node Node) bool {
+		if node != nil {
+			if _, ok := nodeSchemas[reflect.TypeOf(node).Elem()]; !ok {
+				t.Errorf("%T has no JSON kind", node)
+			}
+		}
+		return true
+	})
+
+	data, err := EncodeJSON(&Program{Statements: []Statement{&ExpressionStatment{Expression: ident("x")}}})
+	if err != nil {
+		t.Fatalf("EncodeJSON: %s", err)
+	}
+
+	if !strings.Contains(string(data), `"kind":"ExpressionStatement"`) {
+		t.Errorf("wrong kind for an expression statement. got=%s", data)
+	}
+}
+
+func TestDecodeJSONErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"version":2,"program":{"kind":"Program"}}`, "ast: unsupported JSON version 2, expected 1"},
+		{`{"version":1,"program":{"kind":"Widget"}}`, `ast: unknown node kind "Widget"`},
+		{`{"version":1,"program":{"kind":"Identifier"}}`, "ast: expected a Program, got Identifier"},
+		{
+			`{"version":1,"program":{"kind":"Program","statements":[{"kind":"ReturnStatement","value":{"kind":"BlockStatement"}}]}}`,
+			"ast: Program.statements: [0]: ReturnStatement.value: BlockStatement cannot be used as ast.Expression",
+		},
+	}
+
+	for i, tt := range tests {
+		_, err := DecodeJSON([]byte(tt.input))
+
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("tests[%d] - error wrong. expected=%q, got=%v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestSExpr(t *testing.T) {
+	stmt := &LetStatement{
+		Token: token.Token{Type: token.LET, Literal: "let", Pos: token.Position{Line: 1, Column: 1}},
+		Name:  &Identifier{Token: token.Token{Type: token.IDENTIFIER, Literal: "x", Pos: token.Position{Line: 1, Column: 5}, End: token.Position{Line: 1, Column: 6}}, Value: "x"},
+		Value: &Boolean{Token: token.Token{Type: token.TRUE, Literal: "true", Pos: token.Position{Line: 1, Column: 9}, End: token.Position{Line: 1, Column: 13}}, Value: true},
+	}
+
+	expected := `(LetStatement @1:1-1:13 :token "let"
+  :name (Identifier @1:5-1:6 :token "x" :value "x")
+  :value (Boolean @1:9-1:13 :token "true" :value true))`
+
+	if SExpr(stmt) != expected {
+		t.Errorf("SExpr wrong. expected=\n%s\ngot=\n%s", expected, SExpr(stmt))
+	}
+}
@@ -0,0 +1,377 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"unicode/utf8"
+
+	"github.com/Urvirith/bearlang/src/token"
+)
+
+// Version of the JSON form, raised whenever a node or field is renamed or removed
+const JSONVersion = 1
+
+// JSON key of each field of a node, by Go field name
+type keys map[string]string
+
+// Kind and keys of a node in the JSON form
+type jsonNode struct {
+	node Node
+	kind string
+	keys keys
+}
+
+// Every node with its kind and keys, written out so renaming a Go type or field does not change
+// the form, a change here raises JSONVersion
+var jsonNodes = []jsonNode{
+	{&Program{}, "Program", keys{"Statements": "statements", "Comments": "comments"}},
+	{&Comment{}, "Comment", keys{"Token": "token", "Text": "text"}},
+	{&LetStatement{}, "LetStatement", keys{"Token": "token", "Name": "name", "Type": "type", "Value": "value"}},
+	{&ConstStatement{}, "ConstStatement", keys{"Token": "token", "Name": "name", "Type": "type", "Value": "value"}},
+	{&BlockStatement{}, "BlockStatement", keys{"Token": "token", "Statements": "statements", "Rbrace": "rbrace"}},
+	{&Param{}, "Param", keys{"Name": "name", "Type": "type"}},
+	{&FunctionDecl{}, "FunctionDecl", keys{"Token": "token", "Linkage": "linkage", "Name": "name", "Params": "params", "ReturnType": "returnType", "Body": "body"}},
+	{&IfStatement{}, "IfStatement", keys{"Token": "token", "Condition": "condition", "Consequence": "consequence", "Alternative": "alternative"}},
+	{&LoopStatement{}, "LoopStatement", keys{"Token": "token", "Label": "label", "Body": "body"}},
+	{&WhileStatement{}, "WhileStatement", keys{"Token": "token", "Label": "label", "Condition": "condition", "Body": "body"}},
+	{&ForStatement{}, "ForStatement", keys{"Token": "token", "Label": "label", "Var": "var", "VarType": "varType", "Iterable": "iterable", "Body": "body"}},
+	{&BreakStatement{}, "BreakStatement", keys{"Token": "token", "Label": "label"}},
+	{&ContinueStatement{}, "ContinueStatement", keys{"Token": "token", "Label": "label"}},
+	{&Attribute{}, "Attribute", keys{"Token": "token", "Name": "name", "Args": "args", "Rparen": "rparen"}},
+	{&Field{}, "Field", keys{"Name": "name", "Type": "type"}},
+	{&StructDecl{}, "StructDecl", keys{"Token": "token", "Attributes": "attributes", "Name": "name", "Fields": "fields", "Rbrace": "rbrace"}},
+	{&UnionDecl{}, "UnionDecl", keys{"Token": "token", "Attributes": "attributes", "Name": "name", "Fields": "fields", "Rbrace": "rbrace"}},
+	{&EnumVariant{}, "EnumVariant", keys{"Name": "name", "Value": "value"}},
+	{&EnumDecl{}, "EnumDecl", keys{"Token": "token", "Attributes": "attributes", "Name": "name", "Backing": "backing", "Variants": "variants", "Rbrace": "rbrace"}},
+	{&ReturnStatement{}, "ReturnStatement", keys{"Token": "token", "Value": "value"}},
+	{&AssignStatement{}, "AssignStatement", keys{"Token": "token", "Target": "target", "Value": "value"}},
+	{&PrimitiveType{}, "PrimitiveType", keys{"Token": "token", "Name": "name"}},
+	{&PointerType{}, "PointerType", keys{"Token": "token", "Elem": "elem"}},
+	{&VolatileType{}, "VolatileType", keys{"Token": "token", "Elem": "elem"}},
+	{&ArrayType{}, "ArrayType", keys{"Token": "token", "Len": "len", "Elem": "elem"}},
+	{&NamedType{}, "NamedType", keys{"Token": "token", "Name": "name"}},
+	{&Identifier{}, "Identifier", keys{"Token": "token", "Value": "value"}},
+	{&ExpressionStatment{}, "ExpressionStatement", keys{"Token": "token", "Expression": "expression"}},
+	{&IntegerLiteral{}, "IntegerLiteral", keys{"Token": "token", "Value": "value", "Suffix": "suffix"}},
+	{&FloatLiteral{}, "FloatLiteral", keys{"Token": "token", "Value": "value", "Suffix": "suffix"}},
+	{&CharLiteral{}, "CharLiteral", keys{"Token": "token", "Value": "value"}},
+	{&StringLiteral{}, "StringLiteral", keys{"Token": "token", "Value": "value"}},
+	{&GroupExpression{}, "GroupExpression", keys{"Lparen": "lparen", "Expression": "expression", "Rparen": "rparen"}},
+	{&PrefixExpression{}, "PrefixExpression", keys{"Token": "token", "Operator": "operator", "Right": "right"}},
+	{&DerefExpression{}, "DerefExpression", keys{"Token": "token", "Operand": "operand"}},
+	{&AddressOf{}, "AddressOf", keys{"Token": "token", "Operand": "operand"}},
+	{&CallExpression{}, "CallExpression", keys{"Token": "token", "Function": "function", "Arguments": "arguments", "Rparen": "rparen"}},
+	{&IndexExpression{}, "IndexExpression", keys{"Token": "token", "Left": "left", "Index": "index", "Rbrack": "rbrack"}},
+	{&FieldExpression{}, "FieldExpression", keys{"Token": "token", "Left": "left", "Field": "field"}},
+	{&InfixExpression{}, "InfixExpression", keys{"Token": "token", "Left": "left", "Operator": "operator", "Right": "right"}},
+	{&RangeExpression{}, "RangeExpression", keys{"Token": "token", "Low": "low", "High": "high", "Inclusive": "inclusive"}},
+	{&MatchArm{}, "MatchArm", keys{"Token": "token", "Patterns": "patterns", "Default": "default", "Body": "body"}},
+	{&MatchExpression{}, "MatchExpression", keys{"Token": "token", "Subject": "subject", "Arms": "arms", "Rbrace": "rbrace"}},
+	{&Boolean{}, "Boolean", keys{"Token": "token", "Value": "value"}},
+}
+
+var (
+	nodeKinds   = map[string]reflect.Type{}    // Node type by kind
+	nodeSchemas = map[reflect.Type]*jsonNode{} // Kind and keys by node type
+)
+
+func init() {
+	for i := range jsonNodes {
+		schema := &jsonNodes[i]
+		typ := reflect.TypeOf(schema.node).Elem()
+
+		if len(schema.keys) != typ.NumField() {
+			panic(fmt.Sprintf("ast: JSON keys of %s do not match its %d fields", typ.Name(), typ.NumField()))
+		}
+		for j := 0; j < typ.NumField(); j++ {
+			if _, ok := schema.keys[typ.Field(j).Name]; !ok {
+				panic(fmt.Sprintf("ast: no JSON key for %s.%s", typ.Name(), typ.Field(j).Name))
+			}
+		}
+
+		nodeKinds[schema.kind] = typ
+		nodeSchemas[typ] = schema
+	}
+}
+
+var (
+	tokenType    = reflect.TypeOf(token.Token{})
+	bigIntType   = reflect.TypeOf((*big.Int)(nil))
+	byteListType = reflect.TypeOf([]byte(nil))
+)
+
+// Program with the version of the form it is written in and the file it was read from
+type jsonDocument struct {
+	Version int             `json:"version"`
+	File    string          `json:"file,omitempty"`
+	Program json.RawMessage `json:"program"`
+}
+
+// Position as line, column and byte offset, the file name is kept once on the document
+type jsonPosition [3]int
+
+// Start and end of a node
+type jsonSpan [2]jsonPosition
+
+type jsonToken struct {
+	Type    token.TokenType `json:"type"`
+	Literal string          `json:"literal"`
+	Pos     jsonPosition    `json:"pos"`
+	End     jsonPosition    `json:"end"`
+}
+
+// JSON object keeping its keys in the order they were added
+type jsonObject []jsonMember
+
+type jsonMember struct {
+	key   string
+	value interface{}
+}
+
+func (obj jsonObject) MarshalJSON() ([]byte, error) {
+	var out bytes.Buffer
+
+	out.WriteString("{")
+	for i, m := range obj {
+		if i > 0 {
+			out.WriteString(",")
+		}
+
+		key, _ := json.Marshal(m.key)
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+
+		out.Write(key)
+		out.WriteString(":")
+		out.Write(value)
+	}
+	out.WriteString("}")
+
+	return out.Bytes(), nil
+}
+
+// Encode a program as compact JSON, each node is an object holding its kind, its span and its fields
+//
+//	{"kind":"Identifier","span":[[1,5,4],[1,6,5]],"token":{...},"value":"x"}
+//
+// Positions are [line, column, offset], fields that are nil or zero tokens are left out
+// and empty lists are kept, json.Indent makes it readable
+func EncodeJSON(prg *Program) ([]byte, error) {
+	program, err := json.Marshal(encodeNode(reflect.ValueOf(prg)))
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(jsonDocument{Version: JSONVersion, File: prg.Pos().Filename, Program: program})
+}
+
+// Decode a program written by EncodeJSON
+func DecodeJSON(data []byte) (*Program, error) {
+	var doc jsonDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	if doc.Version != JSONVersion {
+		return nil, fmt.Errorf("ast: unsupported JSON version %d, expected %d", doc.Version, JSONVersion)
+	}
+
+	dec := &jsonDecoder{file: doc.File}
+
+	node, err := dec.decodeNode(doc.Program)
+	if err != nil {
+		return nil, fmt.Errorf("ast: %w", err)
+	}
+
+	prg, ok := node.Interface().(*Program)
+	if !ok {
+		return nil, fmt.Errorf("ast: expected a Program, got %s", nodeSchemas[node.Elem().Type()].kind)
+	}
+
+	return prg, nil
+}
+
+func encodeNode(ptr reflect.Value) jsonObject {
+	node := ptr.Interface().(Node)
+	val := ptr.Elem()
+	schema := nodeSchemas[val.Type()]
+
+	obj := jsonObject{
+		{"kind", schema.kind},
+		{"span", jsonSpan{jsonPos(node.Pos()), jsonPos(node.End())}},
+	}
+
+	for i := 0; i < val.NumField(); i++ {
+		if value, ok := encodeValue(val.Field(i)); ok {
+			obj = append(obj, jsonMember{schema.keys[val.Type().Field(i).Name], value})
+		}
+	}
+
+	return obj
+}
+
+// JSON form of a field, ok is false when it is nil or a zero token and is left out
+func encodeValue(field reflect.Value) (interface{}, bool) {
+	switch {
+	case field.Type() == tokenType:
+		tok := field.Interface().(token.Token)
+		if tok == (token.Token{}) {
+			return nil, false
+		}
+		return jsonToken{Type: tok.Type, Literal: tok.Literal, Pos: jsonPos(tok.Pos), End: jsonPos(tok.End)}, true
+	case field.Type() == bigIntType:
+		if field.IsNil() {
+			return nil, false
+		}
+		return field.Interface().(*big.Int).String(), true
+	case field.Type() == byteListType:
+		if field.IsNil() {
+			return nil, false
+		}
+
+		// Text stays readable, bytes that are not UTF-8 are kept as a list of numbers
+		if utf8.Valid(field.Bytes()) {
+			return string(field.Bytes()), true
+		}
+
+		list := []int{}
+		for _, b := range field.Bytes() {
+			list = append(list, int(b))
+		}
+		return list, true
+	case field.Kind() == reflect.Interface, field.Kind() == reflect.Ptr:
+		if field.IsNil() {
+			return nil, false
+		}
+		if field.Kind() == reflect.Interface {
+			field = field.Elem()
+		}
+		return encodeNode(field), true
+	case field.Kind() == reflect.Slice:
+		if field.IsNil() {
+			return nil, false
+		}
+
+		list := []interface{}{}
+		for i := 0; i < field.Len(); i++ {
+			elem, _ := encodeValue(field.Index(i))
+			list = append(list, elem)
+		}
+		return list, true
+	}
+
+	return field.Interface(), true
+}
+
+// Rebuilds nodes, giving every position the file of the document
+type jsonDecoder struct {
+	file string
+}
+
+func (dec *jsonDecoder) decodeNode(data json.RawMessage) (reflect.Value, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return reflect.Value{}, err
+	}
+
+	var kind string
+	if err := json.Unmarshal(members["kind"], &kind); err != nil {
+		return reflect.Value{}, fmt.Errorf("node without a kind")
+	}
+
+	typ, ok := nodeKinds[kind]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("unknown node kind %q", kind)
+	}
+
+	ptr := reflect.New(typ)
+	schema := nodeSchemas[typ]
+
+	for i := 0; i < typ.NumField(); i++ {
+		name := typ.Field(i).Name
+
+		raw, ok := members[schema.keys[name]]
+		if !ok || string(raw) == "null" {
+			continue
+		}
+
+		if err := dec.decodeValue(raw, ptr.Elem().Field(i)); err != nil {
+			return reflect.Value{}, fmt.Errorf("%s.%s: %w", kind, schema.keys[name], err)
+		}
+	}
+
+	return ptr, nil
+}
+
+func (dec *jsonDecoder) decodeValue(raw json.RawMessage, field reflect.Value) error {
+	switch {
+	case field.Type() == tokenType:
+		var tok jsonToken
+		if err := json.Unmarshal(raw, &tok); err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(token.Token{Type: tok.Type, Literal: tok.Literal, Pos: dec.position(tok.Pos), End: dec.position(tok.End)}))
+	case field.Type() == bigIntType:
+		var digits string
+		if err := json.Unmarshal(raw, &digits); err != nil {
+			return err
+		}
+
+		value, ok := new(big.Int).SetString(digits, 10)
+		if !ok {
+			return fmt.Errorf("invalid integer %q", digits)
+		}
+		field.Set(reflect.ValueOf(value))
+	case field.Type() == byteListType:
+		var text string
+		if err := json.Unmarshal(raw, &text); err == nil {
+			field.SetBytes([]byte(text))
+			return nil
+		}
+
+		var list []byte
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return err
+		}
+		field.SetBytes(list)
+	case field.Kind() == reflect.Interface, field.Kind() == reflect.Ptr:
+		node, err := dec.decodeNode(raw)
+		if err != nil {
+			return err
+		}
+
+		if !node.Type().AssignableTo(field.Type()) {
+			return fmt.Errorf("%s cannot be used as %s", nodeSchemas[node.Elem().Type()].kind, field.Type())
+		}
+		field.Set(node)
+	case field.Kind() == reflect.Slice:
+		var list []json.RawMessage
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return err
+		}
+
+		field.Set(reflect.MakeSlice(field.Type(), len(list), len(list)))
+		for i, elem := range list {
+			if err := dec.decodeValue(elem, field.Index(i)); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+	default:
+		return json.Unmarshal(raw, field.Addr().Interface())
+	}
+
+	return nil
+}
+
+func jsonPos(pos token.Position) jsonPosition {
+	return jsonPosition{pos.Line, pos.Column, pos.Offset}
+}
+
+func (dec *jsonDecoder) position(pos jsonPosition) token.Position {
+	return token.Position{Filename: dec.file, Line: pos[0], Column: pos[1], Offset: pos[2]}
+}
@@ -0,0 +1,104 @@
+package ast
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Urvirith/bearlang/src/token"
+)
+
+// Dump a tree as an S-expression, each node is its kind and span followed by its fields
+//
+//	(LetStatement @1:1-1:11 :token "let"
+//	  :name (Identifier @1:5-1:6 :token "x" :value "x")
+//	  :value (IntegerLiteral @1:9-1:11 :token "10" :value 10 :suffix ""))
+//
+// Spans leave out the file name, fields holding other nodes start a new line and nil fields
+// and zero tokens are left out
+func SExpr(node Node) string {
+	var out strings.Builder
+	writeSExpr(&out, reflect.ValueOf(node), "")
+	return out.String()
+}
+
+func writeSExpr(out *strings.Builder, ptr reflect.Value, indent string) {
+	node := ptr.Interface().(Node)
+	val := ptr.Elem()
+	schema := nodeSchemas[val.Type()]
+
+	start, end := node.Pos(), node.End()
+	fmt.Fprintf(out, "(%s @%d:%d-%d:%d", schema.kind, start.Line, start.Column, end.Line, end.Column)
+
+	// Plain values first so the line of a node reads as a whole
+	for i := 0; i < val.NumField(); i++ {
+		if text, ok := sexprValue(val.Field(i)); ok {
+			fmt.Fprintf(out, " :%s %s", schema.keys[val.Type().Field(i).Name], text)
+		}
+	}
+
+	inner := indent + "  "
+
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Field(i)
+		key := schema.keys[val.Type().Field(i).Name]
+
+		switch {
+		case field.Kind() == reflect.Slice && field.Type() != byteListType:
+			if field.IsNil() {
+				continue
+			}
+
+			fmt.Fprintf(out, "\n%s:%s (", inner, key)
+			for j := 0; j < field.Len(); j++ {
+				fmt.Fprintf(out, "\n%s  ", inner)
+				writeSExpr(out, sexprNode(field.Index(j)), inner+"  ")
+			}
+			out.WriteString(")")
+		case (field.Kind() == reflect.Interface || field.Kind() == reflect.Ptr) && field.Type() != bigIntType:
+			if field.IsNil() {
+				continue
+			}
+
+			fmt.Fprintf(out, "\n%s:%s ", inner, key)
+			writeSExpr(out, sexprNode(field), inner)
+		}
+	}
+
+	out.WriteString(")")
+}
+
+// Pointer to the node held by a field
+func sexprNode(field reflect.Value) reflect.Value {
+	if field.Kind() == reflect.Interface {
+		return field.Elem()
+	}
+	return field
+}
+
+// Text of a field that does not hold nodes, ok is false for node fields and zero tokens
+func sexprValue(field reflect.Value) (string, bool) {
+	switch {
+	case field.Type() == tokenType:
+		tok := field.Interface().(token.Token)
+		if tok == (token.Token{}) {
+			return "", false
+		}
+		return strconv.Quote(tok.Literal), true
+	case field.Type() == bigIntType:
+		if field.IsNil() {
+			return "", false
+		}
+		return field.Interface().(*big.Int).String(), true
+	case field.Type() == byteListType:
+		return strconv.Quote(string(field.Bytes())), true
+	case field.Kind() == reflect.String:
+		return strconv.Quote(field.String()), true
+	case field.Kind() == reflect.Interface, field.Kind() == reflect.Ptr, field.Kind() == reflect.Slice:
+		return "", false
+	}
+
+	return fmt.Sprint(field.Interface()), true
+}
@@ -0,0 +1,414 @@
+package ast
+
+import "fmt"
+
+// A Visitor's Visit is called for each node found by Walk, the children of the node are
+// then walked with the returned visitor, and skipped when it is nil
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Traverse a tree depth first, calling v.Visit(node) and, when that returns a visitor w, walking
+// each child with w in source order and finishing with w.Visit(nil). The comments of a program
+// are walked after all of its statements
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		walkStatements(v, n.Statements)
+		for _, cm := range n.Comments {
+			Walk(v, cm)
+		}
+
+	// Statements
+	case *LetStatement:
+		Walk(v, n.Name)
+		if n.Type != nil {
+			Walk(v, n.Type)
+		}
+		Walk(v, n.Value)
+	case *ConstStatement:
+		Walk(v, n.Name)
+		if n.Type != nil {
+			Walk(v, n.Type)
+		}
+		Walk(v, n.Value)
+	case *BlockStatement:
+		walkStatements(v, n.Statements)
+	case *FunctionDecl:
+		Walk(v, n.Name)
+		for _, param := range n.Params {
+			Walk(v, param)
+		}
+		if n.ReturnType != nil {
+			Walk(v, n.ReturnType)
+		}
+		Walk(v, n.Body)
+	case *Param:
+		Walk(v, n.Name)
+		Walk(v, n.Type)
+	case *IfStatement:
+		Walk(v, n.Condition)
+		Walk(v, n.Consequence)
+		if n.Alternative != nil {
+			Walk(v, n.Alternative)
+		}
+	case *LoopStatement:
+		if n.Label != nil {
+			Walk(v, n.Label)
+		}
+		Walk(v, n.Body)
+	case *WhileStatement:
+		if n.Label != nil {
+			Walk(v, n.Label)
+		}
+		Walk(v, n.Condition)
+		Walk(v, n.Body)
+	case *ForStatement:
+		if n.Label != nil {
+			Walk(v, n.Label)
+		}
+		Walk(v, n.Var)
+		if n.VarType != nil {
+			Walk(v, n.VarType)
+		}
+		Walk(v, n.Iterable)
+		Walk(v, n.Body)
+	case *BreakStatement:
+		if n.Label != nil {
+			Walk(v, n.Label)
+		}
+	case *ContinueStatement:
+		if n.Label != nil {
+			Walk(v, n.Label)
+		}
+	case *ReturnStatement:
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+	case *AssignStatement:
+		Walk(v, n.Target)
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+	case *ExpressionStatment:
+		Walk(v, n.Expression)
+
+	// Declarations
+	case *Attribute:
+		Walk(v, n.Name)
+		walkExpressions(v, n.Args)
+	case *Field:
+		Walk(v, n.Name)
+		Walk(v, n.Type)
+	case *StructDecl:
+		walkDecl(v, n.Attributes, n.Name, n.Fields)
+	case *UnionDecl:
+		walkDecl(v, n.Attributes, n.Name, n.Fields)
+	case *EnumVariant:
+		Walk(v, n.Name)
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+	case *EnumDecl:
+		walkDecl(v, n.Attributes, n.Name, nil)
+		if n.Backing != nil {
+			Walk(v, n.Backing)
+		}
+		for _, variant := range n.Variants {
+			Walk(v, variant)
+		}
+
+	// Types
+	case *PointerType:
+		Walk(v, n.Elem)
+	case *VolatileType:
+		Walk(v, n.Elem)
+	case *ArrayType:
+		Walk(v, n.Len)
+		Walk(v, n.Elem)
+
+	// Expressions
+	case *GroupExpression:
+		Walk(v, n.Expression)
+	case *PrefixExpression:
+		Walk(v, n.Right)
+	case *DerefExpression:
+		Walk(v, n.Operand)
+	case *AddressOf:
+		Walk(v, n.Operand)
+	case *CallExpression:
+		Walk(v, n.Function)
+		walkExpressions(v, n.Arguments)
+	case *IndexExpression:
+		Walk(v, n.Left)
+		Walk(v, n.Index)
+	case *FieldExpression:
+		Walk(v, n.Left)
+		Walk(v, n.Field)
+	case *InfixExpression:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+	case *RangeExpression:
+		Walk(v, n.Low)
+		Walk(v, n.High)
+	case *MatchArm:
+		walkExpressions(v, n.Patterns)
+		Walk(v, n.Body)
+	case *MatchExpression:
+		Walk(v, n.Subject)
+		for _, arm := range n.Arms {
+			Walk(v, arm)
+		}
+
+	// Comments, identifiers, literals and named types have no children
+	case *Comment, *Identifier, *IntegerLiteral, *FloatLiteral, *CharLiteral, *StringLiteral, *Boolean, *PrimitiveType, *NamedType:
+
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+func walkStatements(v Visitor, stmts []Statement) {
+	for _, stmt := range stmts {
+		Walk(v, stmt)
+	}
+}
+
+func walkExpressions(v Visitor, exprs []Expression) {
+	for _, expr := range exprs {
+		Walk(v, expr)
+	}
+}
+
+// Attributes, name and fields shared by struct, union and enum declarations
+func walkDecl(v Visitor, attrs []*Attribute, name *Identifier, fields []*Field) {
+	for _, attr := range attrs {
+		Walk(v, attr)
+	}
+	Walk(v, name)
+	for _, f := range fields {
+		Walk(v, f)
+	}
+}
+
+// Function called for each node by Inspect
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Traverse a tree depth first in the order of Walk, calling f(node) and walking the children
+// of the node only when it returns true, f(nil) is called after the children
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// Traverse a tree bottom up, replacing each node with f(node) once its children are replaced,
+// f returns the node itself to keep it and panics are raised for a replacement that does not fit
+// where the node was, such as a statement in place of an expression
+func Rewrite(node Node, f func(Node) Node) Node {
+	switch n := node.(type) {
+	case *Program:
+		n.Statements = rewriteStatements(n.Statements, f)
+		for i, cm := range n.Comments {
+			n.Comments[i] = rewriteAs(cm, f)
+		}
+
+	// Statements
+	case *LetStatement:
+		n.Name = rewriteAs(n.Name, f)
+		if n.Type != nil {
+			n.Type = rewriteAs(n.Type, f)
+		}
+		n.Value = rewriteAs(n.Value, f)
+	case *ConstStatement:
+		n.Name = rewriteAs(n.Name, f)
+		if n.Type != nil {
+			n.Type = rewriteAs(n.Type, f)
+		}
+		n.Value = rewriteAs(n.Value, f)
+	case *BlockStatement:
+		n.Statements = rewriteStatements(n.Statements, f)
+	case *FunctionDecl:
+		n.Name = rewriteAs(n.Name, f)
+		for i, param := range n.Params {
+			n.Params[i] = rewriteAs(param, f)
+		}
+		if n.ReturnType != nil {
+			n.ReturnType = rewriteAs(n.ReturnType, f)
+		}
+		n.Body = rewriteAs(n.Body, f)
+	case *Param:
+		n.Name = rewriteAs(n.Name, f)
+		n.Type = rewriteAs(n.Type, f)
+	case *IfStatement:
+		n.Condition = rewriteAs(n.Condition, f)
+		n.Consequence = rewriteAs(n.Consequence, f)
+		if n.Alternative != nil {
+			n.Alternative = rewriteAs(n.Alternative, f)
+		}
+	case *LoopStatement:
+		if n.Label != nil {
+			n.Label = rewriteAs(n.Label, f)
+		}
+		n.Body = rewriteAs(n.Body, f)
+	case *WhileStatement:
+		if n.Label != nil {
+			n.Label = rewriteAs(n.Label, f)
+		}
+		n.Condition = rewriteAs(n.Condition, f)
+		n.Body = rewriteAs(n.Body, f)
+	case *ForStatement:
+		if n.Label != nil {
+			n.Label = rewriteAs(n.Label, f)
+		}
+		n.Var = rewriteAs(n.Var, f)
+		if n.VarType != nil {
+			n.VarType = rewriteAs(n.VarType, f)
+		}
+		n.Iterable = rewriteAs(n.Iterable, f)
+		n.Body = rewriteAs(n.Body, f)
+	case *BreakStatement:
+		if n.Label != nil {
+			n.Label = rewriteAs(n.Label, f)
+		}
+	case *ContinueStatement:
+		if n.Label != nil {
+			n.Label = rewriteAs(n.Label, f)
+		}
+	case *ReturnStatement:
+		if n.Value != nil {
+			n.Value = rewriteAs(n.Value, f)
+		}
+	case *AssignStatement:
+		n.Target = rewriteAs(n.Target, f)
+		if n.Value != nil {
+			n.Value = rewriteAs(n.Value, f)
+		}
+	case *ExpressionStatment:
+		n.Expression = rewriteAs(n.Expression, f)
+
+	// Declarations
+	case *Attribute:
+		n.Name = rewriteAs(n.Name, f)
+		n.Args = rewriteExpressions(n.Args, f)
+	case *Field:
+		n.Name = rewriteAs(n.Name, f)
+		n.Type = rewriteAs(n.Type, f)
+	case *StructDecl:
+		n.Attributes, n.Name, n.Fields = rewriteDecl(n.Attributes, n.Name, n.Fields, f)
+	case *UnionDecl:
+		n.Attributes, n.Name, n.Fields = rewriteDecl(n.Attributes, n.Name, n.Fields, f)
+	case *EnumVariant:
+		n.Name = rewriteAs(n.Name, f)
+		if n.Value != nil {
+			n.Value = rewriteAs(n.Value, f)
+		}
+	case *EnumDecl:
+		n.Attributes, n.Name, _ = rewriteDecl(n.Attributes, n.Name, nil, f)
+		if n.Backing != nil {
+			n.Backing = rewriteAs(n.Backing, f)
+		}
+		for i, variant := range n.Variants {
+			n.Variants[i] = rewriteAs(variant, f)
+		}
+
+	// Types
+	case *PointerType:
+		n.Elem = rewriteAs(n.Elem, f)
+	case *VolatileType:
+		n.Elem = rewriteAs(n.Elem, f)
+	case *ArrayType:
+		n.Len = rewriteAs(n.Len, f)
+		n.Elem = rewriteAs(n.Elem, f)
+
+	// Expressions
+	case *GroupExpression:
+		n.Expression = rewriteAs(n.Expression, f)
+	case *PrefixExpression:
+		n.Right = rewriteAs(n.Right, f)
+	case *DerefExpression:
+		n.Operand = rewriteAs(n.Operand, f)
+	case *AddressOf:
+		n.Operand = rewriteAs(n.Operand, f)
+	case *CallExpression:
+		n.Function = rewriteAs(n.Function, f)
+		n.Arguments = rewriteExpressions(n.Arguments, f)
+	case *IndexExpression:
+		n.Left = rewriteAs(n.Left, f)
+		n.Index = rewriteAs(n.Index, f)
+	case *FieldExpression:
+		n.Left = rewriteAs(n.Left, f)
+		n.Field = rewriteAs(n.Field, f)
+	case *InfixExpression:
+		n.Left = rewriteAs(n.Left, f)
+		n.Right = rewriteAs(n.Right, f)
+	case *RangeExpression:
+		n.Low = rewriteAs(n.Low, f)
+		n.High = rewriteAs(n.High, f)
+	case *MatchArm:
+		n.Patterns = rewriteExpressions(n.Patterns, f)
+		n.Body = rewriteAs(n.Body, f)
+	case *MatchExpression:
+		n.Subject = rewriteAs(n.Subject, f)
+		for i, arm := range n.Arms {
+			n.Arms[i] = rewriteAs(arm, f)
+		}
+
+	// Comments, identifiers, literals and named types have no children
+	case *Comment, *Identifier, *IntegerLiteral, *FloatLiteral, *CharLiteral, *StringLiteral, *Boolean, *PrimitiveType, *NamedType:
+
+	default:
+		panic(fmt.Sprintf("ast.Rewrite: unexpected node type %T", n))
+	}
+
+	return f(node)
+}
+
+// Rewrite a child and verify its replacement fits the field it came from
+func rewriteAs[T Node](node T, f func(Node) Node) T {
+	out := Rewrite(node, f)
+
+	replaced, ok := out.(T)
+	if !ok {
+		panic(fmt.Sprintf("ast.Rewrite: cannot replace %T with %T", node, out))
+	}
+
+	return replaced
+}
+
+func rewriteStatements(stmts []Statement, f func(Node) Node) []Statement {
+	for i, stmt := range stmts {
+		stmts[i] = rewriteAs(stmt, f)
+	}
+	return stmts
+}
+
+func rewriteExpressions(exprs []Expression, f func(Node) Node) []Expression {
+	for i, expr := range exprs {
+		exprs[i] = rewriteAs(expr, f)
+	}
+	return exprs
+}
+
+// Attributes, name and fields shared by struct, union and enum declarations
+func rewriteDecl(attrs []*Attribute, name *Identifier, fields []*Field, f func(Node) Node) ([]*Attribute, *Identifier, []*Field) {
+	for i, attr := range attrs {
+		attrs[i] = rewriteAs(attr, f)
+	}
+	name = rewriteAs(name, f)
+	for i, fl := range fields {
+		fields[i] = rewriteAs(fl, f)
+	}
+	return attrs, name, fields
+}
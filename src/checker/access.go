@@ -0,0 +1,78 @@
+package checker
+
+import (
+	"github.com/Urvirith/bearlang/src/ast"
+	"github.com/Urvirith/bearlang/src/diag"
+)
+
+// Verify only arrays are indexed
+func (chk *Checker) checkIndex(ie *ast.IndexExpression) {
+	chk.checkExpression(ie.Left)
+	chk.checkExpression(ie.Index)
+
+	typ := chk.typeOf(ie.Left)
+	if typ == nil {
+		return
+	}
+
+	if _, ok := unqualified(typ).(*ast.ArrayType); !ok {
+		chk.errorf(diag.IndexNonArray, diag.TokenSpan(ie.Token), "cannot index %s of type %s, it is not an array", ie.Left, typ)
+	}
+}
+
+// Verify the field exists on the struct or union being accessed
+func (chk *Checker) checkField(fe *ast.FieldExpression) {
+	chk.checkExpression(fe.Left)
+
+	typ := chk.typeOf(fe.Left)
+	if typ == nil {
+		return
+	}
+
+	named, ok := unqualified(typ).(*ast.NamedType)
+	if !ok {
+		chk.errorf(diag.UnknownField, diag.TokenSpan(fe.Field.Token), "%s of type %s has no fields", fe.Left, typ)
+		return
+	}
+
+	if chk.fieldsOf(named) == nil {
+		// Unknown types are reported by the resolver
+		return
+	}
+
+	if chk.lookupField(typ, fe.Field.Value) == nil {
+		chk.errorf(diag.UnknownField, diag.TokenSpan(fe.Field.Token), "%s has no field %s", named.Name, fe.Field.Value)
+	}
+}
+
+// Find a field of a struct or union type, nil when there is no such field
+func (chk *Checker) lookupField(typ ast.TypeExpr, name string) *ast.Field {
+	named, ok := unqualified(typ).(*ast.NamedType)
+	if !ok {
+		return nil
+	}
+
+	for _, field := range chk.fieldsOf(named) {
+		if field.Name.Value == name {
+			return field
+		}
+	}
+
+	return nil
+}
+
+// Fields of the struct or union a type names, nil for any other type
+func (chk *Checker) fieldsOf(named *ast.NamedType) []*ast.Field {
+	sym := chk.res.TypeUse(named)
+	if sym == nil {
+		return nil
+	}
+
+	switch decl := sym.Decl.(type) {
+	case *ast.StructDecl:
+		return decl.Fields
+	case *ast.UnionDecl:
+		return decl.Fields
+	}
+	return nil
+}
@@ -0,0 +1,333 @@
+package checker
+
+import (
+	"math/big"
+
+	"github.com/Urvirith/bearlang/src/ast"
+	"github.com/Urvirith/bearlang/src/consteval"
+	"github.com/Urvirith/bearlang/src/diag"
+	"github.com/Urvirith/bearlang/src/resolver"
+	"github.com/Urvirith/bearlang/src/token"
+)
+
+// Structure defining the Checker
+type Checker struct {
+	prg    *ast.Program
+	consts *consteval.Evaluator
+	res    *resolver.Resolver       // Declaration of each name, its errors are reported by the resolver
+	enums  map[string]*ast.EnumDecl // Enums of the program by name
+	owners map[string][]string      // Enums declaring each variant name
+	diags  diag.List
+}
+
+// Create new instance for a parsed program
+func New(prg *ast.Program) *Checker {
+	chk := &Checker{
+		prg:    prg,
+		consts: consteval.New(prg),
+		res:    resolver.New(prg),
+		enums:  make(map[string]*ast.EnumDecl),
+		owners: make(map[string][]string),
+	}
+
+	for _, stmt := range prg.Statements {
+		switch decl := stmt.(type) {
+		case *ast.EnumDecl:
+			chk.enums[decl.Name.Value] = decl
+			for _, v := range decl.Variants {
+				chk.owners[v.Name.Value] = append(chk.owners[v.Name.Value], decl.Name.Value)
+			}
+		}
+	}
+
+	return chk
+}
+
+// Run every check over the program and return the errors found
+func (chk *Checker) Check() []string {
+	chk.res.Resolve()
+	chk.checkStatements(chk.prg.Statements)
+
+	return chk.diags.Strings()
+}
+
+// Return errors found while checking
+func (chk *Checker) Errors() []string {
+	return chk.diags.Strings()
+}
+
+// Return errors and warnings found while checking
+func (chk *Checker) Diagnostics() diag.List {
+	return chk.diags
+}
+
+// Check each statement in order
+func (chk *Checker) checkStatements(stmts []ast.Statement) {
+	for _, stmt := range stmts {
+		chk.checkStatement(stmt)
+	}
+}
+
+func (chk *Checker) checkStatement(stmt ast.Statement) {
+	switch node := stmt.(type) {
+	case *ast.LetStatement:
+		chk.checkExpression(node.Value)
+		chk.checkVolatile(node.Name.Token.Pos, node.Value, chk.typeOf(node.Value), node.Type)
+	case *ast.ConstStatement:
+		chk.checkExpression(node.Value)
+		chk.checkVolatile(node.Name.Token.Pos, node.Value, chk.typeOf(node.Value), node.Type)
+	case *ast.AssignStatement:
+		chk.checkAssign(node)
+	case *ast.ReturnStatement:
+		chk.checkExpression(node.Value)
+	case *ast.ExpressionStatment:
+		chk.checkExpression(node.Expression)
+	case *ast.BlockStatement:
+		chk.checkStatements(node.Statements)
+	case *ast.FunctionDecl:
+		chk.checkStatements(node.Body.Statements)
+	case *ast.IfStatement:
+		chk.checkExpression(node.Condition)
+		chk.checkStatement(node.Consequence)
+		if node.Alternative != nil {
+			chk.checkStatement(node.Alternative)
+		}
+	case *ast.LoopStatement:
+		chk.checkStatement(node.Body)
+	case *ast.WhileStatement:
+		chk.checkExpression(node.Condition)
+		chk.checkStatement(node.Body)
+	case *ast.EnumDecl:
+		chk.checkEnum(node)
+	case *ast.ForStatement:
+		chk.checkExpression(node.Iterable)
+		chk.checkStatements(node.Body.Statements)
+	}
+}
+
+func (chk *Checker) checkExpression(expr ast.Expression) {
+	switch node := expr.(type) {
+	case *ast.GroupExpression:
+		chk.checkExpression(node.Expression)
+	case *ast.PrefixExpression:
+		chk.checkExpression(node.Right)
+	case *ast.DerefExpression:
+		chk.checkDeref(node)
+	case *ast.AddressOf:
+		chk.checkAddressOf(node)
+	case *ast.CallExpression:
+		chk.checkExpression(node.Function)
+		for _, arg := range node.Arguments {
+			chk.checkExpression(arg)
+		}
+	case *ast.IndexExpression:
+		chk.checkIndex(node)
+	case *ast.FieldExpression:
+		chk.checkField(node)
+	case *ast.InfixExpression:
+		chk.checkExpression(node.Left)
+		chk.checkExpression(node.Right)
+	case *ast.RangeExpression:
+		chk.checkExpression(node.Low)
+		chk.checkExpression(node.High)
+	case *ast.MatchExpression:
+		chk.checkExpression(node.Subject)
+		for _, arm := range node.Arms {
+			chk.checkStatement(arm.Body)
+		}
+		chk.checkMatch(node)
+	}
+}
+
+// Verify each discriminant fits the backing type and is used by one variant only
+func (chk *Checker) checkEnum(decl *ast.EnumDecl) {
+	backing := "u32"
+	if prim, ok := decl.Backing.(*ast.PrimitiveType); ok {
+		backing = prim.Name
+	}
+
+	min, max, ok := token.IntRange(backing)
+	if !ok {
+		// The layout calculator reports enums that are not backed by an integer
+		return
+	}
+
+	next := big.NewInt(0)
+	used := map[string]*ast.EnumVariant{}
+
+	for _, v := range decl.Variants {
+		value := next
+
+		if v.Value != nil {
+			val, err := chk.consts.Eval(v.Value)
+			if err != nil {
+				chk.diags = append(chk.diags, diag.FromError(err))
+				return
+			}
+
+			if val.Kind != consteval.Int {
+				chk.errorf(diag.DiscriminantRange, diag.TokenSpan(v.Name.Token), "discriminant of %s must be an integer, got %s", v.Name.Value, val)
+				return
+			}
+			value = val.Int
+		}
+
+		if value.Cmp(min) < 0 || value.Cmp(max) > 0 {
+			chk.errorf(diag.DiscriminantRange, diag.TokenSpan(v.Name.Token), "discriminant %s of %s does not fit in %s", value, v.Name.Value, backing)
+		}
+
+		if other, ok := used[value.String()]; ok {
+			chk.diags = append(chk.diags, diag.Errorf(diag.DuplicateDiscriminant, diag.TokenSpan(v.Name.Token),
+				"discriminant %s of %s is already used by %s", value, v.Name.Value, other.Name.Value).
+				WithLabel(diag.TokenSpan(other.Name.Token), "%s declared here", other.Name.Value))
+		}
+
+		used[value.String()] = v
+		next = new(big.Int).Add(value, big.NewInt(1))
+	}
+}
+
+// Verify the target of an assignment can be written
+func (chk *Checker) checkAssign(as *ast.AssignStatement) {
+	chk.checkExpression(as.Target)
+	chk.checkExpression(as.Value)
+
+	// Only variables and parameters can be written, Enum.Variant binds its field to the variant
+	var ident *ast.Identifier
+	switch node := ast.Unparen(as.Target).(type) {
+	case *ast.Identifier:
+		ident = node
+	case *ast.FieldExpression:
+		ident = node.Field
+	}
+
+	if ident != nil {
+		if sym := chk.res.Use(ident); sym != nil && sym.Kind != resolver.Var && sym.Kind != resolver.Param {
+			chk.errorf(diag.AssignConstant, diag.TokenSpan(ident.Token), "cannot assign to %s %s", sym.Kind, ident.Value)
+		}
+	}
+
+	if as.Token.Type == token.ASSIGN {
+		chk.checkVolatile(as.Token.Pos, as.Value, chk.typeOf(as.Value), chk.typeOf(as.Target))
+	}
+}
+
+// Declared type of the name an identifier uses, nil when it is inferred or the name is not a variable
+func (chk *Checker) declType(ident *ast.Identifier) ast.TypeExpr {
+	sym := chk.res.Use(ident)
+	if sym == nil {
+		return nil
+	}
+
+	switch decl := sym.Decl.(type) {
+	case *ast.LetStatement:
+		return decl.Type
+	case *ast.ConstStatement:
+		return decl.Type
+	case *ast.Param:
+		return decl.Type
+	case *ast.ForStatement:
+		return decl.VarType
+	}
+	return nil
+}
+
+// Name of the primitive type of an expression, "" when it cannot be told without inference
+func (chk *Checker) primitiveOf(expr ast.Expression) string {
+	switch node := expr.(type) {
+	case *ast.GroupExpression:
+		return chk.primitiveOf(node.Expression)
+	case *ast.Boolean:
+		return "bool"
+	case *ast.CharLiteral:
+		return "u8"
+	case *ast.IntegerLiteral:
+		return node.Suffix
+	case *ast.Identifier, *ast.DerefExpression, *ast.IndexExpression, *ast.FieldExpression:
+		if prim, ok := unqualified(chk.typeOf(node)).(*ast.PrimitiveType); ok {
+			return prim.Name
+		}
+	}
+	return ""
+}
+
+// Add an error over a span
+func (chk *Checker) errorf(code diag.Code, span diag.Span, format string, args ...interface{}) {
+	chk.diags = append(chk.diags, diag.Errorf(code, span, format, args...))
+}
+
+// Add a warning over a span
+func (chk *Checker) warningf(code diag.Code, span diag.Span, format string, args ...interface{}) {
+	chk.diags = append(chk.diags, diag.Warningf(code, span, format, args...))
+}
+
+// Values of an integer type as closed intervals, kept sorted and merged
+type intervals [][2]*big.Int
+
+// Add the values lo..=hi
+func (ivs intervals) add(lo *big.Int, hi *big.Int) intervals {
+	out := intervals{}
+	one := big.NewInt(1)
+
+	for _, iv := range ivs {
+		// Intervals that overlap or touch are merged into the new one
+		if new(big.Int).Add(iv[1], one).Cmp(lo) >= 0 && new(big.Int).Sub(iv[0], one).Cmp(hi) <= 0 {
+			if iv[0].Cmp(lo) < 0 {
+				lo = iv[0]
+			}
+			if iv[1].Cmp(hi) > 0 {
+				hi = iv[1]
+			}
+			continue
+		}
+		out = append(out, iv)
+	}
+
+	out = append(out, [2]*big.Int{lo, hi})
+
+	// Keep the intervals sorted by their start
+	for i := len(out) - 1; i > 0 && out[i][0].Cmp(out[i-1][0]) < 0; i-- {
+		out[i], out[i-1] = out[i-1], out[i]
+	}
+
+	return out
+}
+
+// Verify every value of lo..=hi is already present
+func (ivs intervals) covers(lo *big.Int, hi *big.Int) bool {
+	for _, iv := range ivs {
+		if iv[0].Cmp(lo) <= 0 && iv[1].Cmp(hi) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// First run of values in lo..=hi that is missing, ok is false when nothing is missing
+func (ivs intervals) gap(lo *big.Int, hi *big.Int) (*big.Int, *big.Int, bool) {
+	next := lo
+	one := big.NewInt(1)
+
+	for _, iv := range ivs {
+		if iv[0].Cmp(next) > 0 {
+			return next, new(big.Int).Sub(iv[0], one), true
+		}
+		if iv[1].Cmp(next) >= 0 {
+			next = new(big.Int).Add(iv[1], one)
+		}
+	}
+
+	if next.Cmp(hi) <= 0 {
+		return next, hi, true
+	}
+
+	return nil, nil, false
+}
+
+// Format a run of values as a single value or a range
+func formatRun(lo *big.Int, hi *big.Int) string {
+	if lo.Cmp(hi) == 0 {
+		return lo.String()
+	}
+	return lo.String() + "..=" + hi.String()
+}
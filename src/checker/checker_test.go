@@ -0,0 +1,241 @@
+package checker
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Urvirith/bearlang/src/diag"
+	"github.com/Urvirith/bearlang/src/lexer"
+	"github.com/Urvirith/bearlang/src/parser"
+)
+
+func check(t *testing.T, input string) []string {
+	psr := parser.New(lexer.New(input))
+	prg := psr.ParseProgram()
+
+	if len(psr.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", psr.Errors())
+	}
+
+	return New(prg).Check()
+}
+
+func TestMatchExhaustive(t *testing.T) {
+	tests := []string{
+		"fn f(x: u32) { match x { 0 => a, 1 | 2 => b, 3..=9 => { c; } default => d } }",
+		"fn f(b: bool) { match b { true => 1, false => 0 } }",
+		"fn f(x: u8) { match x { 0..128 => 1, 128..=255 => 2 } }",
+		"const IDLE: u8 = 0; const RUN: u8 = 1; fn f(s: i8) { match s { IDLE => 1, RUN => 2, -128..0 => 3, 2..=127 => 4 } }",
+		"fn f(c: u8) { match c { 'A' => 1, default => 0 } }",
+	}
+
+	for _, input := range tests {
+		if errors := check(t, input); len(errors) != 0 {
+			t.Errorf("%q: unexpected errors %q", input, errors)
+		}
+	}
+}
+
+func TestMatchErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn f(b: bool) { match b { true => 1 } }", "1:17: non-exhaustive match, false is not covered"},
+		{"fn f(x: u8) { match x { 0..=100 => 1, 200..=255 => 2 } }", "1:15: non-exhaustive match, 101..=199 is not covered"},
+		{"fn f() { match y { 0 => 1 } }", "1:10: non-exhaustive match, integers of unknown type need a default arm"},
+		{"fn f(x: u32) { match x { 0..=9 => 1, 5 => 2, default => 3 } }", "1:38: warning: unreachable match arm, its patterns are covered by earlier arms"},
+		{"fn f(x: u32) { match x { default => 1, 5 => 2 } }", "1:40: warning: unreachable match arm, it follows the default arm"},
+		{"fn f(b: bool) { match b { true => 1, false => 0, true => 2 } }", "1:50: warning: unreachable match arm, its patterns are covered by earlier arms"},
+		{"fn f(x: u8) { match x { 256 => 1, default => 0 } }", "1:25: pattern 256 is out of range for u8"},
+		{"fn f(x: u8) { match x { 5..5 => 1, default => 0 } }", "1:26: range pattern 5..5 is empty"},
+		{"fn f(x: u8) { match x { y => 1, default => 0 } }", "1:25: y is not a constant"},
+		{"fn f(x: u8) { match x { 1 => 1, true => 0, default => 0 } }", "1:33: pattern true is a bool, earlier patterns are integer"},
+	}
+
+	for _, tt := range tests {
+		errors := check(t, tt.input)
+
+		if len(errors) != 1 || errors[0] != tt.expected {
+			t.Errorf("%q: expected error %q. got=%q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestEnums(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"enum Mode { Idle, Run, Stop } fn f(m: Mode) { match m { Idle => 0, Run | Stop => 1 } }", nil},
+		{"enum Mode: u8 { Idle = 254, Run, Stop }", []string{"1:34: discriminant 256 of Stop does not fit in u8"}},
+		{"enum Mode { Idle = 2, Run = 1, Stop }", []string{"1:32: discriminant 2 of Stop is already used by Idle"}},
+		{"enum Mode: i8 { Low = -1, Zero, High = BASE } const BASE: i8 = 0;", []string{"1:33: discriminant 0 of High is already used by Zero"}},
+		{"enum Mode { Idle, Run, Stop } fn f(m: Mode) { match m { Idle => 0 } }", []string{"1:47: non-exhaustive match, Mode variants Run, Stop are not covered"}},
+		{"enum Mode { Idle, Run } fn f(m: Mode) { match m { Idle => 0, Idle | Run => 1, Run => 2 } }", []string{"1:79: warning: unreachable match arm, its patterns are covered by earlier arms"}},
+		{"enum A { X } enum B { X } fn f(a: u32) { match a { X => 0, default => 1 } }", []string{"1:52: variant X is declared by more than one enum: A, B"}},
+		{"enum A { X, Y } enum B { X } fn f(a: A) { match a { X => 0, Y => 1 } }", nil},
+		{"enum A { X, Y } enum B { X } fn f(a: A) { match a { X => 0 } }", []string{"1:43: non-exhaustive match, A variants Y are not covered"}},
+		{"enum Mode { Input, Output } fn f(m: Mode) { match m { Mode.Input => 1, Mode.Output => 2 } }", nil},
+		{"enum Mode { Input, Output } fn f(m: Mode) { match m { Mode.Input => 1 } }", []string{"1:45: non-exhaustive match, Mode variants Output are not covered"}},
+		{"enum Mode { Input, Output } fn f(m: Mode) { match m { Mode.Input => 1, Input => 2, default => 3 } }", []string{"1:72: warning: unreachable match arm, its patterns are covered by earlier arms"}},
+		{"enum Mode { Idle } fn f(m: Mode) { match m { Idle => 0, 1 => 1 } }", []string{"1:57: pattern 1 is a integer, earlier patterns are Mode"}},
+	}
+
+	for _, tt := range tests {
+		errors := check(t, tt.input)
+
+		if len(errors) != len(tt.expected) {
+			t.Errorf("%q: expected errors %q. got=%q", tt.input, tt.expected, errors)
+			continue
+		}
+
+		for i, err := range errors {
+			if err != tt.expected[i] {
+				t.Errorf("%q: expected error %q. got=%q", tt.input, tt.expected[i], err)
+			}
+		}
+	}
+}
+
+func TestAssignErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"fn f() { let x: u32 = 0; x += 1; x++; }", nil},
+		{"fn f() { LIMIT = 1; } const LIMIT: u32 = 4;", []string{"1:10: cannot assign to constant LIMIT"}},
+		{"fn f() { const N: u8 = 1; N++; }", []string{"1:27: cannot assign to constant N"}},
+		{"fn f() { (LIMIT) = 1; } const LIMIT: u32 = 4;", []string{"1:11: cannot assign to constant LIMIT"}},
+		{"const N: u8 = 1; fn f(N: u8) { N -= 1; }", nil},
+		{"fn f(x: u8) { match x { 0 => N = 2, default => 0 } } const N: u8 = 1;", []string{"1:30: cannot assign to constant N"}},
+		{"enum Mode { Input } fn f() { Input = 2; }", []string{"1:30: cannot assign to enum variant Input"}},
+		{"enum Mode { Input } fn f() { Mode.Input = 2; }", []string{"1:35: cannot assign to enum variant Input"}},
+		{"fn g() {} fn f() { g = 2; }", []string{"1:20: cannot assign to function g"}},
+		{"fn f() { *1 = 2; }", []string{"1:10: cannot dereference 1, it is not a pointer"}},
+		{"fn f() { *(1u8) = 2; }", []string{"1:10: cannot dereference 1u8 of type u8, it is not a pointer"}},
+	}
+
+	for _, tt := range tests {
+		errors := check(t, tt.input)
+
+		if len(errors) != len(tt.expected) {
+			t.Errorf("%q: expected errors %q. got=%q", tt.input, tt.expected, errors)
+			continue
+		}
+
+		for i, err := range errors {
+			if err != tt.expected[i] {
+				t.Errorf("%q: expected error %q. got=%q", tt.input, tt.expected[i], err)
+			}
+		}
+	}
+}
+
+func TestPointers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"const REG: vol u32* = 0x40021000; fn f() { *REG |= 1; let v: u32 = *REG; }", nil},
+		{"fn f(p: u32*) { let q: vol u32* = p; let r: u32** = &p; *q = **r; }", nil},
+		{"fn f(x: u8*) { match *x { 0..=127 => 0, 128..=255 => 1 } }", nil},
+		{"fn f(x: u32) { *x = 1; }", []string{"1:16: cannot dereference x of type u32, it is not a pointer"}},
+		{"fn f(p: u32*) { let x: u32 = **p; }", []string{"1:30: cannot dereference (*p) of type u32, it is not a pointer"}},
+		{"const REG: vol u32* = 0x40021000; fn f() { let p: u32* = REG; }", []string{"1:48: cannot use REG of type vol u32* as u32*, it drops vol"}},
+		{"fn f(r: vol u8) { let p: u8* = &r; }", []string{"1:23: cannot use (&r) of type vol u8* as u8*, it drops vol"}},
+		{"fn f(p: vol u8**, q: u8**) { q = p; }", []string{"1:32: cannot use p of type vol u8** as u8**, it drops vol"}},
+		{"fn f(p: vol u8*, q: u8*) { q += p; }", nil},
+		{"fn f() { let p: u8* = &5; }", []string{"1:23: cannot take the address of 5"}},
+		{"const N: u8 = 1; fn f() { let p: u8* = &N; }", []string{"1:40: cannot take the address of constant N"}},
+		{"const N: u8 = 1; fn f() { let p: u8* = &(N); }", []string{"1:40: cannot take the address of constant N"}},
+	}
+
+	for _, tt := range tests {
+		errors := check(t, tt.input)
+
+		if len(errors) != len(tt.expected) {
+			t.Errorf("%q: expected errors %q. got=%q", tt.input, tt.expected, errors)
+			continue
+		}
+
+		for i, err := range errors {
+			if err != tt.expected[i] {
+				t.Errorf("%q: expected error %q. got=%q", tt.input, tt.expected[i], err)
+			}
+		}
+	}
+}
+
+func TestFieldsAndIndexes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"struct Gpio { moder: u32, pins: [16]u8 } fn f(g: Gpio*) { (*g).moder |= 1; (*g).pins[2] = 0; }", nil},
+		{"struct Gpio { moder: u32 } fn f(g: vol Gpio*) { let p: vol u32* = &(*g).moder; }", nil},
+		{"struct Gpio { moder: u32 } fn f(g: vol Gpio*) { let p: u32* = &(*g).moder; }", []string{"1:53: cannot use (&(*g).moder) of type vol u32* as u32*, it drops vol"}},
+		{"fn f(buf: [4]vol u8) { let p: u8* = &buf[0]; }", []string{"1:28: cannot use (&(buf[0])) of type vol u8* as u8*, it drops vol"}},
+		{"fn f(x: u32) { x[0] = 1; }", []string{"1:17: cannot index x of type u32, it is not an array"}},
+		{"struct Gpio { moder: u32 } fn f(g: Gpio) { g.odr = 1; }", []string{"1:46: Gpio has no field odr"}},
+		{"fn f(x: u32) { x.low = 1; }", []string{"1:18: x of type u32 has no fields"}},
+		{"fn f(buf: [4]u8) { match buf[0] { 0..=255 => 1 } }", nil},
+		{"fn f(x: u32) { write(x[0]); }", []string{"1:23: cannot index x of type u32, it is not an array"}},
+	}
+
+	for _, tt := range tests {
+		errors := check(t, tt.input)
+
+		if len(errors) != len(tt.expected) {
+			t.Errorf("%q: expected errors %q. got=%q", tt.input, tt.expected, errors)
+			continue
+		}
+
+		for i, err := range errors {
+			if err != tt.expected[i] {
+				t.Errorf("%q: expected error %q. got=%q", tt.input, tt.expected[i], err)
+			}
+		}
+	}
+}
+
+func TestDiagnosticSeverities(t *testing.T) {
+	tests := []struct {
+		input    string
+		severity diag.Severity
+		code     diag.Code
+		label    string
+	}{
+		{"fn f(x: u32) { match x { default => 1, 5 => 2 } }", diag.Warning, diag.UnreachableArm, "1:26: default arm is here"},
+		{"fn f(b: bool) { match b { true => 1 } }", diag.Error, diag.NonExhaustive, ""},
+		{"enum Mode { Idle = 1, Run = 1 }", diag.Error, diag.DuplicateDiscriminant, "1:13: Idle declared here"},
+		{"const X: u8 = 1; fn f() { X = 2; }", diag.Error, diag.AssignConstant, ""},
+	}
+
+	for i, tt := range tests {
+		psr := parser.New(lexer.New(tt.input))
+		chk := New(psr.ParseProgram())
+		chk.Check()
+
+		diags := chk.Diagnostics()
+		if len(diags) != 1 {
+			t.Errorf("tests[%d] - expected 1 diagnostic. got=%q", i, diags.Strings())
+			continue
+		}
+
+		d := diags[0]
+
+		if d.Severity != tt.severity || d.Code != tt.code {
+			t.Errorf("tests[%d] - expected %s %s. got=%s %s", i, tt.severity, tt.code, d.Severity, d.Code)
+		}
+
+		label := ""
+		if len(d.Labels) != 0 {
+			label = fmt.Sprintf("%s: %s", d.Labels[0].Span.Start, d.Labels[0].Message)
+		}
+
+		if label != tt.label {
+			t.Errorf("tests[%d] - label wrong. expected=%q, got=%q", i, tt.label, label)
+		}
+	}
+}
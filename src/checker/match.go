@@ -0,0 +1,236 @@
+package checker
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/Urvirith/bearlang/src/ast"
+	"github.com/Urvirith/bearlang/src/consteval"
+	"github.com/Urvirith/bearlang/src/diag"
+	"github.com/Urvirith/bearlang/src/resolver"
+	"github.com/Urvirith/bearlang/src/token"
+)
+
+// Kinds of values a match can be over
+const (
+	matchInt  = "integer"
+	matchBool = "bool"
+)
+
+// Values covered by a single pattern
+type pattern struct {
+	kind    string   // matchInt, matchBool or the name of an enum
+	lo      *big.Int // First integer value
+	hi      *big.Int // Last integer value, inclusive
+	bool    bool
+	enum    *ast.EnumDecl
+	variant string
+}
+
+// Report match arms that can never be taken and matches that do not cover every value
+func (chk *Checker) checkMatch(me *ast.MatchExpression) {
+	subject := chk.primitiveOf(me.Subject)
+	min, max, sized := token.IntRange(subject)
+	enum := chk.enumOf(me.Subject)
+
+	kind := ""
+	ints := intervals{}
+	bools := map[bool]bool{}
+	variants := map[string]bool{}
+	var defaultArm *ast.MatchArm
+
+	for _, arm := range me.Arms {
+		if defaultArm != nil {
+			chk.diags = append(chk.diags, diag.Warningf(diag.UnreachableArm, diag.TokenSpan(arm.Token),
+				"unreachable match arm, it follows the default arm").
+				WithLabel(diag.TokenSpan(defaultArm.Token), "default arm is here"))
+			continue
+		}
+
+		if arm.Default {
+			defaultArm = arm
+			continue
+		}
+
+		reachable := false
+
+		for _, expr := range arm.Patterns {
+			pat, ok := chk.evalPattern(expr, enum)
+			if !ok {
+				// The error is already reported, do not report the arm as well
+				reachable = true
+				continue
+			}
+
+			if kind != "" && pat.kind != kind {
+				chk.errorf(diag.PatternKind, diag.NodeSpan(expr), "pattern %s is a %s, earlier patterns are %s", expr, pat.kind, kind)
+				reachable = true
+				continue
+			}
+			kind = pat.kind
+			if pat.enum != nil {
+				enum = pat.enum
+			}
+
+			switch pat.kind {
+			case matchBool:
+				if !bools[pat.bool] {
+					reachable = true
+				}
+				bools[pat.bool] = true
+			case matchInt:
+				if sized && (pat.lo.Cmp(min) < 0 || pat.hi.Cmp(max) > 0) {
+					chk.errorf(diag.PatternRange, diag.NodeSpan(expr), "pattern %s is out of range for %s", expr, subject)
+				}
+				if !ints.covers(pat.lo, pat.hi) {
+					reachable = true
+				}
+				ints = ints.add(pat.lo, pat.hi)
+			default:
+				if !variants[pat.variant] {
+					reachable = true
+				}
+				variants[pat.variant] = true
+			}
+		}
+
+		if !reachable {
+			chk.warningf(diag.UnreachableArm, diag.TokenSpan(arm.Token), "unreachable match arm, its patterns are covered by earlier arms")
+		}
+	}
+
+	if defaultArm != nil {
+		return
+	}
+
+	switch kind {
+	case matchBool:
+		for _, b := range []bool{true, false} {
+			if !bools[b] {
+				chk.errorf(diag.NonExhaustive, diag.TokenSpan(me.Token), "non-exhaustive match, %t is not covered", b)
+			}
+		}
+	case matchInt:
+		if !sized {
+			chk.errorf(diag.NonExhaustive, diag.TokenSpan(me.Token), "non-exhaustive match, integers of unknown type need a default arm")
+			return
+		}
+		if lo, hi, missing := ints.gap(min, max); missing {
+			chk.errorf(diag.NonExhaustive, diag.TokenSpan(me.Token), "non-exhaustive match, %s is not covered", formatRun(lo, hi))
+		}
+	case "":
+		chk.errorf(diag.NonExhaustive, diag.TokenSpan(me.Token), "non-exhaustive match, add a default arm")
+	default:
+		missing := []string{}
+		for _, v := range enum.Variants {
+			if !variants[v.Name.Value] {
+				missing = append(missing, v.Name.Value)
+			}
+		}
+
+		if len(missing) != 0 {
+			chk.errorf(diag.NonExhaustive, diag.TokenSpan(me.Token), "non-exhaustive match, %s variants %s are not covered", kind, strings.Join(missing, ", "))
+		}
+	}
+}
+
+// Enum the subject of a match is declared as, nil when it is not a known enum
+func (chk *Checker) enumOf(subject ast.Expression) *ast.EnumDecl {
+	named, ok := unqualified(chk.typeOf(subject)).(*ast.NamedType)
+	if !ok {
+		return nil
+	}
+
+	sym := chk.res.TypeUse(named)
+	if sym == nil {
+		return nil
+	}
+
+	decl, _ := sym.Decl.(*ast.EnumDecl)
+	return decl
+}
+
+// Fold a pattern into the values it covers, reporting patterns that are not constant. Bare variant
+// names are looked up in enum first, the enum of the subject when it is known
+func (chk *Checker) evalPattern(expr ast.Expression, enum *ast.EnumDecl) (pattern, bool) {
+	expr = ast.Unparen(expr)
+
+	if rng, ok := expr.(*ast.RangeExpression); ok {
+		start, ok := chk.evalPattern(rng.Low, enum)
+		if !ok {
+			return pattern{}, false
+		}
+
+		end, ok := chk.evalPattern(rng.High, enum)
+		if !ok {
+			return pattern{}, false
+		}
+
+		if start.kind != matchInt || end.kind != matchInt {
+			chk.errorf(diag.PatternKind, diag.TokenSpan(rng.Token), "range pattern %s must be over integers", rng)
+			return pattern{}, false
+		}
+
+		hi := end.hi
+		if !rng.Inclusive {
+			hi = new(big.Int).Sub(hi, big.NewInt(1))
+		}
+
+		if start.lo.Cmp(hi) > 0 {
+			chk.errorf(diag.EmptyPattern, diag.TokenSpan(rng.Token), "range pattern %s is empty", rng)
+			return pattern{}, false
+		}
+
+		return pattern{kind: matchInt, lo: start.lo, hi: hi}, true
+	}
+
+	// Enum.Variant names its enum, the resolver reports a variant the enum does not have
+	if fe, ok := expr.(*ast.FieldExpression); ok {
+		if ident, ok := fe.Left.(*ast.Identifier); ok {
+			if sym := chk.res.Use(ident); sym != nil && sym.Kind == resolver.Enum {
+				if chk.res.Use(fe.Field) == nil {
+					return pattern{}, false
+				}
+				decl := sym.Decl.(*ast.EnumDecl)
+				return pattern{kind: decl.Name.Value, enum: decl, variant: fe.Field.Value}, true
+			}
+		}
+	}
+
+	if ident, ok := expr.(*ast.Identifier); ok {
+		// A variant of the enum of the subject
+		if enum != nil {
+			for _, v := range enum.Variants {
+				if v.Name.Value == ident.Value {
+					return pattern{kind: enum.Name.Value, enum: enum, variant: ident.Value}, true
+				}
+			}
+		}
+
+		// Otherwise a name declared by exactly one enum is a variant of that enum
+		switch owners := chk.owners[ident.Value]; len(owners) {
+		case 0:
+		case 1:
+			return pattern{kind: owners[0], enum: chk.enums[owners[0]], variant: ident.Value}, true
+		default:
+			chk.errorf(diag.AmbiguousVariant, diag.TokenSpan(ident.Token), "variant %s is declared by more than one enum: %s", ident.Value, strings.Join(owners, ", "))
+			return pattern{}, false
+		}
+	}
+
+	val, err := chk.consts.Eval(expr)
+	if err != nil {
+		chk.diags = append(chk.diags, diag.FromError(err))
+		return pattern{}, false
+	}
+
+	switch val.Kind {
+	case consteval.Bool:
+		return pattern{kind: matchBool, bool: val.Bool}, true
+	case consteval.Int:
+		return pattern{kind: matchInt, lo: val.Int, hi: val.Int}, true
+	}
+
+	chk.errorf(diag.PatternKind, diag.NodeSpan(expr), "pattern %s must be an integer or bool", expr)
+	return pattern{}, false
+}
@@ -0,0 +1,137 @@
+package checker
+
+import (
+	"github.com/Urvirith/bearlang/src/ast"
+	"github.com/Urvirith/bearlang/src/diag"
+	"github.com/Urvirith/bearlang/src/resolver"
+	"github.com/Urvirith/bearlang/src/token"
+)
+
+// Declared type of an expression, nil when it cannot be told without inference
+func (chk *Checker) typeOf(expr ast.Expression) ast.TypeExpr {
+	switch node := expr.(type) {
+	case *ast.GroupExpression:
+		return chk.typeOf(node.Expression)
+	case *ast.Identifier:
+		return chk.declType(node)
+	case *ast.Boolean:
+		return &ast.PrimitiveType{Token: node.Token, Name: "bool"}
+	case *ast.CharLiteral:
+		return &ast.PrimitiveType{Token: node.Token, Name: "u8"}
+	case *ast.IntegerLiteral:
+		if node.Suffix != "" {
+			return &ast.PrimitiveType{Token: node.Token, Name: node.Suffix}
+		}
+	case *ast.FloatLiteral:
+		if node.Suffix != "" {
+			return &ast.PrimitiveType{Token: node.Token, Name: node.Suffix}
+		}
+	case *ast.DerefExpression:
+		// The element keeps its vol, reading through a vol u32* is a vol u32
+		if ptr, ok := unqualified(chk.typeOf(node.Operand)).(*ast.PointerType); ok {
+			return ptr.Elem
+		}
+	case *ast.AddressOf:
+		if elem := chk.typeOf(node.Operand); elem != nil {
+			return &ast.PointerType{Token: node.Token, Elem: elem}
+		}
+	case *ast.IndexExpression:
+		left := chk.typeOf(node.Left)
+		if arr, ok := unqualified(left).(*ast.ArrayType); ok {
+			return qualify(left, arr.Elem)
+		}
+	case *ast.FieldExpression:
+		left := chk.typeOf(node.Left)
+		if field := chk.lookupField(left, node.Field.Value); field != nil {
+			return qualify(left, field.Type)
+		}
+	}
+	return nil
+}
+
+// Verify only pointers are dereferenced
+func (chk *Checker) checkDeref(de *ast.DerefExpression) {
+	chk.checkExpression(de.Operand)
+
+	typ := chk.typeOf(de.Operand)
+	if typ == nil {
+		// A literal is never a pointer, even when its type is left to inference
+		switch ast.Unparen(de.Operand).(type) {
+		case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral:
+			chk.errorf(diag.DerefNonPointer, diag.NodeSpan(de), "cannot dereference %s, it is not a pointer", de.Operand)
+		}
+		return
+	}
+
+	if _, ok := unqualified(typ).(*ast.PointerType); !ok {
+		chk.errorf(diag.DerefNonPointer, diag.NodeSpan(de), "cannot dereference %s of type %s, it is not a pointer", de.Operand, typ)
+	}
+}
+
+// Verify the operand of & is a variable or another place in memory
+func (chk *Checker) checkAddressOf(ao *ast.AddressOf) {
+	chk.checkExpression(ao.Operand)
+
+	switch node := ast.Unparen(ao.Operand).(type) {
+	case *ast.Identifier:
+		if sym := chk.res.Use(node); sym != nil && sym.Kind == resolver.Const {
+			chk.errorf(diag.InvalidAddress, diag.TokenSpan(ao.Token), "cannot take the address of constant %s", node.Value)
+		}
+	case *ast.DerefExpression, *ast.IndexExpression, *ast.FieldExpression:
+	default:
+		chk.errorf(diag.InvalidAddress, diag.NodeSpan(ao), "cannot take the address of %s", ao.Operand)
+	}
+}
+
+// Verify storing a value of type from in a place of type to does not drop vol from what it points at
+func (chk *Checker) checkVolatile(pos token.Position, value ast.Expression, from ast.TypeExpr, to ast.TypeExpr) {
+	if from == nil || to == nil {
+		return
+	}
+
+	fromElem, toElem := from, to
+
+	for {
+		fromPtr, ok := unqualified(fromElem).(*ast.PointerType)
+		if !ok {
+			return
+		}
+
+		toPtr, ok := unqualified(toElem).(*ast.PointerType)
+		if !ok {
+			return
+		}
+
+		_, fromVol := fromPtr.Elem.(*ast.VolatileType)
+		_, toVol := toPtr.Elem.(*ast.VolatileType)
+
+		if fromVol && !toVol {
+			chk.errorf(diag.DropsVolatile, diag.At(pos), "cannot use %s of type %s as %s, it drops vol", value, from, to)
+			return
+		}
+
+		fromElem, toElem = fromPtr.Elem, toPtr.Elem
+	}
+}
+
+// Give an element of outer the vol of outer, every field of a vol struct is vol
+func qualify(outer ast.TypeExpr, elem ast.TypeExpr) ast.TypeExpr {
+	vol, ok := outer.(*ast.VolatileType)
+	if !ok {
+		return elem
+	}
+
+	if _, ok := elem.(*ast.VolatileType); ok {
+		return elem
+	}
+
+	return &ast.VolatileType{Token: vol.Token, Elem: elem}
+}
+
+// Remove vol from the outside of a type
+func unqualified(typ ast.TypeExpr) ast.TypeExpr {
+	if vol, ok := typ.(*ast.VolatileType); ok {
+		return vol.Elem
+	}
+	return typ
+}
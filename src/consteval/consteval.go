@@ -0,0 +1,460 @@
+package consteval
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/Urvirith/bearlang/src/ast"
+	"github.com/Urvirith/bearlang/src/diag"
+	"github.com/Urvirith/bearlang/src/token"
+)
+
+// Kinds of values known at compile time
+type Kind int
+
+const (
+	Int Kind = iota
+	Float
+	Bool
+)
+
+// Value of a folded constant expression
+type Value struct {
+	Kind  Kind
+	Type  string // Sized type such as u32 or f32, empty when untyped
+	Int   *big.Int
+	Float float64
+	Bool  bool
+}
+
+func (val Value) String() string {
+	switch val.Kind {
+	case Float:
+		return fmt.Sprintf("%g", val.Float)
+	case Bool:
+		return fmt.Sprintf("%t", val.Bool)
+	}
+	return val.Int.String()
+}
+
+// Evaluation state of a constant, used to find cycles, the zero value is not yet visited
+const (
+	visiting = iota + 1
+	done
+)
+
+// Structure defining the Evaluator
+type Evaluator struct {
+	consts map[string]*ast.ConstStatement // Every const in the program by name
+	order  []string                       // Const names in source order
+	values map[string]Value               // Folded values of the consts
+	state  map[string]int                 // Evaluation state of each const
+	path   []string                       // Consts currently being evaluated, in order
+	diags  diag.List
+}
+
+// Create new instance from the const statements of a program
+func New(prg *ast.Program) *Evaluator {
+	ev := &Evaluator{
+		consts: make(map[string]*ast.ConstStatement),
+		values: make(map[string]Value),
+		state:  make(map[string]int),
+	}
+
+	for _, stmt := range prg.Statements {
+		if cs, ok := stmt.(*ast.ConstStatement); ok {
+			ev.consts[cs.Name.Value] = cs
+			ev.order = append(ev.order, cs.Name.Value)
+		}
+	}
+
+	return ev
+}
+
+// Fold every const of the program, consts with errors are left out of the result
+func (ev *Evaluator) Evaluate() map[string]Value {
+	for _, name := range ev.order {
+		ev.evalConst(name)
+	}
+
+	return ev.values
+}
+
+// Fold a single expression that may refer to the consts of the program, the error is returned rather than kept
+func (ev *Evaluator) Eval(expr ast.Expression) (Value, error) {
+	return ev.eval(expr, "")
+}
+
+// Return errors found while folding
+func (ev *Evaluator) Errors() []string {
+	return ev.diags.Strings()
+}
+
+// Return the diagnostics found while folding
+func (ev *Evaluator) Diagnostics() diag.List {
+	return ev.diags
+}
+
+// Fold a const by name, its dependencies are folded first
+func (ev *Evaluator) evalConst(name string) (Value, error) {
+	cs := ev.consts[name]
+
+	switch ev.state[name] {
+	case done:
+		if val, ok := ev.values[name]; ok {
+			return val, nil
+		}
+		return Value{}, errorf(diag.ConstInvalid, cs.Name.Token, "constant %s has errors", name)
+	case visiting:
+		// Report the cycle from where it starts
+		start := 0
+		for i, n := range ev.path {
+			if n == name {
+				start = i
+			}
+		}
+		cycle := append(append([]string{}, ev.path[start:]...), name)
+		return Value{}, errorf(diag.ConstCycle, cs.Name.Token, "constant %s refers to itself through %s", name, strings.Join(cycle, " -> "))
+	}
+
+	ev.state[name] = visiting
+	ev.path = append(ev.path, name)
+
+	typ := typeName(cs.Type)
+	val, err := ev.eval(cs.Value, typ)
+	if err == nil {
+		val, err = convert(val, typ, cs.Value)
+	}
+
+	ev.path = ev.path[:len(ev.path)-1]
+	ev.state[name] = done
+
+	if err != nil {
+		// The error is passed up to the const that started the evaluation so it is only reported once
+		if len(ev.path) == 0 {
+			ev.diags = append(ev.diags, diag.FromError(err))
+		}
+		return Value{}, err
+	}
+
+	ev.values[name] = val
+	return val, nil
+}
+
+// Fold an expression, typ is the type expected by the context and sizes untyped literals
+func (ev *Evaluator) eval(expr ast.Expression, typ string) (Value, error) {
+	switch node := expr.(type) {
+	case *ast.IntegerLiteral:
+		if node.Suffix != "" {
+			typ = node.Suffix
+		}
+		if isFloatType(typ) {
+			f, _ := new(big.Float).SetInt(node.Value).Float64()
+			return Value{Kind: Float, Type: typ, Float: f}, nil
+		}
+		return Value{Kind: Int, Type: intType(typ), Int: new(big.Int).Set(node.Value)}, nil
+	case *ast.FloatLiteral:
+		if node.Suffix != "" {
+			typ = node.Suffix
+		}
+		return Value{Kind: Float, Type: floatType(typ), Float: node.Value}, nil
+	case *ast.CharLiteral:
+		return Value{Kind: Int, Type: "u8", Int: big.NewInt(int64(node.Value))}, nil
+	case *ast.Boolean:
+		return Value{Kind: Bool, Bool: node.Value}, nil
+	case *ast.Identifier:
+		if _, ok := ev.consts[node.Value]; !ok {
+			return Value{}, errorf(diag.NotConstant, node.Token, "%s is not a constant", node.Value)
+		}
+		return ev.evalConst(node.Value)
+	case *ast.GroupExpression:
+		return ev.eval(node.Expression, typ)
+	case *ast.PrefixExpression:
+		right, err := ev.eval(node.Right, typ)
+		if err != nil {
+			return Value{}, err
+		}
+		return prefix(node, right)
+	case *ast.InfixExpression:
+		return ev.evalInfix(node, typ)
+	case nil:
+		return Value{}, errorAt(diag.NotConstant, diag.Span{}, "missing constant expression")
+	}
+
+	return Value{}, errorAt(diag.NotConstant, diag.NodeSpan(expr), "%s is not a constant expression", expr.String())
+}
+
+// Fold an infix expression, a typed operand sizes the other when it is untyped
+func (ev *Evaluator) evalInfix(node *ast.InfixExpression, typ string) (Value, error) {
+	// Comparisons and logic produce bool, their operands are sized by each other only
+	operandType := typ
+	switch node.Operator {
+	case "==", "!=", "<", ">", "<=", ">=", "&&", "||":
+		operandType = ""
+	}
+
+	left, err := ev.eval(node.Left, operandType)
+	if err != nil {
+		return Value{}, err
+	}
+
+	// The shift amount never takes the type of the shifted value
+	rightType := operandType
+	if rightType == "" {
+		rightType = left.Type
+	}
+	if node.Operator == "<<" || node.Operator == ">>" {
+		rightType = ""
+	}
+
+	right, err := ev.eval(node.Right, rightType)
+	if err != nil {
+		return Value{}, err
+	}
+
+	return infix(node, left, right)
+}
+
+// Apply a prefix operator
+func prefix(node *ast.PrefixExpression, right Value) (Value, error) {
+	switch {
+	case node.Operator == "!" && right.Kind == Bool:
+		return Value{Kind: Bool, Bool: !right.Bool}, nil
+	case node.Operator == "-" && right.Kind == Int:
+		return checked(node.Token, Value{Kind: Int, Type: right.Type, Int: new(big.Int).Neg(right.Int)})
+	case node.Operator == "-" && right.Kind == Float:
+		return Value{Kind: Float, Type: right.Type, Float: -right.Float}, nil
+	case node.Operator == "~" && right.Kind == Int:
+		// Unsigned complements flip only the bits of the type
+		min, max, ok := token.IntRange(right.Type)
+		if ok && min.Sign() == 0 {
+			return Value{Kind: Int, Type: right.Type, Int: new(big.Int).Xor(right.Int, max)}, nil
+		}
+		return Value{Kind: Int, Type: right.Type, Int: new(big.Int).Not(right.Int)}, nil
+	}
+
+	return Value{}, errorf(diag.InvalidOperand, node.Token, "operator %s cannot be applied to %s", node.Operator, right)
+}
+
+// Apply an infix operator
+func infix(node *ast.InfixExpression, left Value, right Value) (Value, error) {
+	if left.Kind != right.Kind {
+		return Value{}, errorf(diag.TypeMismatch, node.Token, "mismatched operands %s %s %s", left, node.Operator, right)
+	}
+
+	switch left.Kind {
+	case Bool:
+		switch node.Operator {
+		case "&&":
+			return Value{Kind: Bool, Bool: left.Bool && right.Bool}, nil
+		case "||":
+			return Value{Kind: Bool, Bool: left.Bool || right.Bool}, nil
+		case "==":
+			return Value{Kind: Bool, Bool: left.Bool == right.Bool}, nil
+		case "!=":
+			return Value{Kind: Bool, Bool: left.Bool != right.Bool}, nil
+		}
+	case Float:
+		return infixFloat(node, left, right)
+	case Int:
+		return infixInt(node, left, right)
+	}
+
+	return Value{}, errorf(diag.InvalidOperand, node.Token, "operator %s cannot be applied to %s and %s", node.Operator, left, right)
+}
+
+// Apply an infix operator to two floats
+func infixFloat(node *ast.InfixExpression, left Value, right Value) (Value, error) {
+	typ := left.Type
+	if typ == "" {
+		typ = right.Type
+	}
+
+	switch node.Operator {
+	case "+":
+		return Value{Kind: Float, Type: typ, Float: left.Float + right.Float}, nil
+	case "-":
+		return Value{Kind: Float, Type: typ, Float: left.Float - right.Float}, nil
+	case "*":
+		return Value{Kind: Float, Type: typ, Float: left.Float * right.Float}, nil
+	case "/":
+		if right.Float == 0 {
+			return Value{}, errorf(diag.DivisionByZero, node.Token, "division by zero")
+		}
+		return Value{Kind: Float, Type: typ, Float: left.Float / right.Float}, nil
+	case "==":
+		return Value{Kind: Bool, Bool: left.Float == right.Float}, nil
+	case "!=":
+		return Value{Kind: Bool, Bool: left.Float != right.Float}, nil
+	case "<":
+		return Value{Kind: Bool, Bool: left.Float < right.Float}, nil
+	case ">":
+		return Value{Kind: Bool, Bool: left.Float > right.Float}, nil
+	case "<=":
+		return Value{Kind: Bool, Bool: left.Float <= right.Float}, nil
+	case ">=":
+		return Value{Kind: Bool, Bool: left.Float >= right.Float}, nil
+	}
+
+	return Value{}, errorf(diag.InvalidOperand, node.Token, "operator %s cannot be applied to %s and %s", node.Operator, left, right)
+}
+
+// Apply an infix operator to two integers, results are checked against the type of the operands
+func infixInt(node *ast.InfixExpression, left Value, right Value) (Value, error) {
+	typ := left.Type
+	if typ == "" {
+		typ = right.Type
+	}
+
+	if left.Type != "" && right.Type != "" && left.Type != right.Type && node.Operator != "<<" && node.Operator != ">>" {
+		return Value{}, errorf(diag.TypeMismatch, node.Token, "mismatched types %s and %s", left.Type, right.Type)
+	}
+
+	l, r := left.Int, right.Int
+	res := new(big.Int)
+
+	switch node.Operator {
+	case "+":
+		res.Add(l, r)
+	case "-":
+		res.Sub(l, r)
+	case "*":
+		res.Mul(l, r)
+	case "/", "%":
+		if r.Sign() == 0 {
+			return Value{}, errorf(diag.DivisionByZero, node.Token, "division by zero")
+		}
+		if node.Operator == "/" {
+			res.Quo(l, r)
+		} else {
+			res.Rem(l, r)
+		}
+	case "&":
+		res.And(l, r)
+	case "|":
+		res.Or(l, r)
+	case "^":
+		res.Xor(l, r)
+	case "<<", ">>":
+		if r.Sign() < 0 || !r.IsInt64() || r.Int64() > 128 {
+			return Value{}, errorf(diag.InvalidShift, node.Token, "invalid shift amount %s", r)
+		}
+		typ = left.Type
+		if node.Operator == "<<" {
+			res.Lsh(l, uint(r.Int64()))
+		} else {
+			res.Rsh(l, uint(r.Int64()))
+		}
+	case "==":
+		return Value{Kind: Bool, Bool: l.Cmp(r) == 0}, nil
+	case "!=":
+		return Value{Kind: Bool, Bool: l.Cmp(r) != 0}, nil
+	case "<":
+		return Value{Kind: Bool, Bool: l.Cmp(r) < 0}, nil
+	case ">":
+		return Value{Kind: Bool, Bool: l.Cmp(r) > 0}, nil
+	case "<=":
+		return Value{Kind: Bool, Bool: l.Cmp(r) <= 0}, nil
+	case ">=":
+		return Value{Kind: Bool, Bool: l.Cmp(r) >= 0}, nil
+	default:
+		return Value{}, errorf(diag.InvalidOperand, node.Token, "operator %s cannot be applied to %s and %s", node.Operator, left, right)
+	}
+
+	return checked(node.Token, Value{Kind: Int, Type: typ, Int: res})
+}
+
+// Verify an integer result fits its type
+func checked(tok token.Token, val Value) (Value, error) {
+	if min, max, ok := token.IntRange(val.Type); ok && (val.Int.Cmp(min) < 0 || val.Int.Cmp(max) > 0) {
+		return Value{}, errorf(diag.ConstOverflow, tok, "constant %s overflows %s", val.Int, val.Type)
+	}
+
+	return val, nil
+}
+
+// Give a folded value the declared type of its const
+func convert(val Value, typ string, expr ast.Expression) (Value, error) {
+	switch {
+	case typ == "":
+		return val, nil
+	case (typ == "bool") != (val.Kind == Bool):
+		return Value{}, errorAt(diag.TypeMismatch, diag.NodeSpan(expr), "constant %s cannot have type %s", val, typ)
+	case typ == "bool":
+		return val, nil
+	case typ == "*":
+		// Addresses are any non negative integer
+		if val.Kind != Int || val.Int.Sign() < 0 {
+			return Value{}, errorAt(diag.TypeMismatch, diag.NodeSpan(expr), "constant %s is not a valid address", val)
+		}
+		val.Type = ""
+		return val, nil
+	case isFloatType(typ):
+		if val.Kind == Int {
+			f, _ := new(big.Float).SetInt(val.Int).Float64()
+			return Value{Kind: Float, Type: typ, Float: f}, nil
+		}
+		val.Type = typ
+		return val, nil
+	}
+
+	if val.Kind != Int {
+		return Value{}, errorAt(diag.TypeMismatch, diag.NodeSpan(expr), "constant %s cannot have type %s", val, typ)
+	}
+
+	if val.Type != "" && val.Type != typ {
+		return Value{}, errorAt(diag.TypeMismatch, diag.NodeSpan(expr), "constant of type %s cannot have type %s", val.Type, typ)
+	}
+
+	min, max, ok := token.IntRange(typ)
+	if ok && (val.Int.Cmp(min) < 0 || val.Int.Cmp(max) > 0) {
+		return Value{}, errorAt(diag.ConstOverflow, diag.NodeSpan(expr), "constant %s does not fit in %s", val.Int, typ)
+	}
+
+	val.Type = typ
+	return val, nil
+}
+
+// Name of the type used to size untyped literals, * for any pointer
+func typeName(typ ast.TypeExpr) string {
+	switch node := typ.(type) {
+	case *ast.PrimitiveType:
+		return node.Name
+	case *ast.VolatileType:
+		return typeName(node.Elem)
+	case *ast.PointerType:
+		return "*"
+	}
+	return ""
+}
+
+// Integer type names size integer values, anything else leaves them untyped
+func intType(typ string) string {
+	if _, _, ok := token.IntRange(typ); ok {
+		return typ
+	}
+	return ""
+}
+
+// Float type names size float values, anything else leaves them untyped
+func floatType(typ string) string {
+	if isFloatType(typ) {
+		return typ
+	}
+	return ""
+}
+
+func isFloatType(typ string) bool {
+	return typ == "f32" || typ == "f64"
+}
+
+// Create an error at the position of a token
+func errorf(code diag.Code, tok token.Token, format string, args ...interface{}) error {
+	return diag.Errorf(code, diag.TokenSpan(tok), format, args...)
+}
+
+// Create an error over a span
+func errorAt(code diag.Code, span diag.Span, format string, args ...interface{}) error {
+	return diag.Errorf(code, span, format, args...)
+}
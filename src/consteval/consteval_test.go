@@ -0,0 +1,103 @@
+package consteval
+
+import (
+	"testing"
+
+	"github.com/Urvirith/bearlang/src/ast"
+	"github.com/Urvirith/bearlang/src/lexer"
+	"github.com/Urvirith/bearlang/src/parser"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	psr := parser.New(lexer.New(input))
+	prg := psr.ParseProgram()
+
+	if len(psr.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", psr.Errors())
+	}
+
+	return prg
+}
+
+func TestEvaluateRegisterMap(t *testing.T) {
+	input := `
+	const GPIOA_MODER:  vol u32* = (GPIOA_BASE + 0x00);        /* Declared before its base */
+	const GPIOA_BASE:   u32 = 0x42020000;
+	const GPIOA_OTYPER: vol u32* = (GPIOA_BASE + 0x04);
+	const PORTC_PIN7:   u32 = 7;
+	const LED_GRN:      u32 = PORTC_PIN7;
+	const SHIFT:        u32 = LED_GRN * 2;
+	const NEG:          i8 = -128;
+	const HALF:         f32 = 1 / 2.0;
+	const ON:           bool = !false;
+	`
+
+	tests := []struct {
+		name     string
+		expected string
+		typ      string
+	}{
+		{"GPIOA_BASE", "1107427328", "u32"},
+		{"GPIOA_MODER", "1107427328", ""},
+		{"GPIOA_OTYPER", "1107427332", ""},
+		{"LED_GRN", "7", "u32"},
+		{"SHIFT", "14", "u32"},
+		{"NEG", "-128", "i8"},
+		{"HALF", "0.5", "f32"},
+		{"ON", "true", ""},
+	}
+
+	ev := New(parse(t, input))
+	values := ev.Evaluate()
+
+	if len(ev.Errors()) != 0 {
+		t.Fatalf("unexpected errors: %v", ev.Errors())
+	}
+
+	for _, tt := range tests {
+		val, ok := values[tt.name]
+		if !ok {
+			t.Errorf("%s was not evaluated", tt.name)
+			continue
+		}
+
+		if val.String() != tt.expected {
+			t.Errorf("%s not %s. got=%s", tt.name, tt.expected, val)
+		}
+
+		if val.Type != tt.typ {
+			t.Errorf("%s type not %q. got=%q", tt.name, tt.typ, val.Type)
+		}
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"const A: u32 = B;\nconst B: u32 = A;", "1:7: constant A refers to itself through A -> B -> A"},
+		{"const A: u32 = A + 1;", "1:7: constant A refers to itself through A -> A"},
+		{"let x: u32 = 1;\nconst A: u32 = x + 1;", "2:16: x is not a constant"},
+		{"const A: u8 = 200 + 100;", "1:19: constant 300 overflows u8"},
+		{"const A: u32 = 10 / (5 - 5);", "1:19: division by zero"},
+		{"const A: u32 = true;", "1:16: constant true cannot have type u32"},
+		{"const A: u8 = 1u16;", "1:15: literal 1u16 has type u16, expected u8"},
+	}
+
+	for _, tt := range tests {
+		psr := parser.New(lexer.New(tt.input))
+		prg := psr.ParseProgram()
+
+		errors := psr.Errors()
+		if len(errors) == 0 {
+			ev := New(prg)
+			ev.Evaluate()
+			errors = ev.Errors()
+		}
+
+		if len(errors) != 1 || errors[0] != tt.expected {
+			t.Errorf("%q: expected error %q. got=%q", tt.input, tt.expected, errors)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package diag
+
+// Stable code of a kind of diagnostic, codes are never reused once published
+type Code string
+
+// Lexer
+const (
+	IllegalCharacter    Code = "E0001" // A character that cannot start a token
+	InvalidNumber       Code = "E0002" // Malformed digits, prefix, exponent or suffix
+	UnterminatedLiteral Code = "E0003" // Char or string literal without its closing quote
+	InvalidEscape       Code = "E0004" // Unknown or unfinished escape sequence
+	CharLength          Code = "E0005" // Char literal that is not a single byte
+	UnterminatedComment Code = "E0006" // Block comment without its closing */
+)
+
+// Parser
+const (
+	UnexpectedToken    Code = "E0100" // A token other than the one the grammar requires
+	ExpectedExpression Code = "E0101" // A token that cannot start an expression
+	ExpectedType       Code = "E0102" // A token that cannot start a type
+	ExpectedDecl       Code = "E0103" // Attributes not followed by a struct, union or enum
+	ExpectedLoop       Code = "E0104" // Label not followed by a loop
+	BranchOutsideLoop  Code = "E0105" // break or continue with no loop around it
+	UnknownLabel       Code = "E0106" // break or continue naming a label no loop has
+	InvalidAssign      Code = "E0107" // Assignment to something that is not a place in memory
+	InvalidLiteral     Code = "E0108" // Literal that cannot be decoded
+	LiteralRange       Code = "E0109" // Literal that does not fit its type
+	LiteralType        Code = "E0110" // Literal whose type does not match the declared type
+	ChainedRange       Code = "E0111" // a..b..c
+)
+
+// Constant evaluation
+const (
+	NotConstant    Code = "E0200" // Name or expression that cannot be folded
+	ConstCycle     Code = "E0201" // Constant defined in terms of itself
+	ConstOverflow  Code = "E0202" // Value does not fit the type of the constant
+	DivisionByZero Code = "E0203"
+	InvalidOperand Code = "E0204" // Operator applied to values it does not accept
+	TypeMismatch   Code = "E0205" // Operands or declared type of different types
+	InvalidShift   Code = "E0206" // Negative or oversized shift amount
+	ConstInvalid   Code = "E0207" // Constant that refers to a constant with errors
+)
+
+// Checker
+const (
+	NonExhaustive         Code = "E0300" // match that does not cover every value
+	UnreachableArm        Code = "W0301" // match arm that can never be taken
+	PatternRange          Code = "E0302" // Pattern outside the type of the subject
+	EmptyPattern          Code = "E0303" // Range pattern with no values
+	PatternKind           Code = "E0304" // Patterns of different kinds in one match
+	DiscriminantRange     Code = "E0305" // Enum discriminant outside the backing type
+	DuplicateDiscriminant Code = "E0306" // Two variants with the same discriminant
+	AmbiguousVariant      Code = "E0307" // Variant name declared by several enums
+	AssignConstant        Code = "E0308" // Assignment to a constant, enum variant or function
+	DerefNonPointer       Code = "E0309" // * applied to a value that is not a pointer
+	InvalidAddress        Code = "E0310" // & applied to something with no address
+	DropsVolatile         Code = "E0311" // Pointer conversion losing vol
+	IndexNonArray         Code = "E0312" // [] applied to a value that is not an array
+	UnknownField          Code = "E0313" // . naming a field the type does not have
+)
+
+// Layout
+const (
+	UnknownType      Code = "E0400" // Named type with no declaration
+	RecursiveType    Code = "E0401" // Type containing itself by value
+	InvalidAttribute Code = "E0402" // Unknown attribute or wrong arguments
+	InvalidAlign     Code = "E0403" // @align that is not a power of two or too small
+	EnumBacking      Code = "E0404" // Enum backed by something other than an integer
+	InvalidLength    Code = "E0405" // Array length that is negative or not constant
+	UnsizedType      Code = "E0406" // Type whose size cannot be known
+	TypeTooLarge     Code = "E0407" // Type larger than the address space of the target
+)
+
+// Resolver
+const (
+	UndefinedName   Code = "E0500" // Name with no declaration in scope
+	DuplicateDecl   Code = "E0501" // Name declared twice in one scope
+	UseBeforeDecl   Code = "E0502" // Local used above its declaration
+	DuplicateMember Code = "E0503" // Field or variant named twice in one declaration
+)
@@ -0,0 +1,172 @@
+package diag
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Urvirith/bearlang/src/token"
+)
+
+// How serious a diagnostic is, errors stop the build and warnings do not
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+)
+
+func (sev Severity) String() string {
+	if sev == Warning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Source range, End is the position immediately after the last character
+type Span struct {
+	Start token.Position
+	End   token.Position
+}
+
+// Span covering a single token
+func TokenSpan(tok token.Token) Span {
+	return Span{Start: tok.Pos, End: tok.End}
+}
+
+// Anything with a source range, such as an ast.Node
+type Ranged interface {
+	Pos() token.Position
+	End() token.Position
+}
+
+// Span covering a whole node, from its first character to its last
+func NodeSpan(node Ranged) Span {
+	return Span{Start: node.Pos(), End: node.End()}
+}
+
+// Empty span at a position, for errors that are not about a whole token
+func At(pos token.Position) Span {
+	return Span{Start: pos, End: pos}
+}
+
+// Secondary span with its own message, such as where a name was first declared
+type Label struct {
+	Span    Span
+	Message string
+}
+
+// Suggested change, Span is replaced by Replacement and an empty span is an insertion
+type Fix struct {
+	Span        Span
+	Replacement string
+	Message     string
+}
+
+// A single error or warning found in the source
+type Diagnostic struct {
+	Severity Severity
+	Code     Code   // Stable code, E0100
+	Message  string // Message on the primary span, without the position
+	Span     Span   // Primary span the message is about
+	Labels   []Label
+	Notes    []string
+	Fix      *Fix // nil when there is no suggestion
+}
+
+// Create an error on a span
+func Errorf(code Code, span Span, format string, args ...interface{}) Diagnostic {
+	return Diagnostic{Severity: Error, Code: code, Message: fmt.Sprintf(format, args...), Span: span}
+}
+
+// Create a warning on a span
+func Warningf(code Code, span Span, format string, args ...interface{}) Diagnostic {
+	return Diagnostic{Severity: Warning, Code: code, Message: fmt.Sprintf(format, args...), Span: span}
+}
+
+// Copy of the diagnostic with a secondary labelled span added
+func (d Diagnostic) WithLabel(span Span, format string, args ...interface{}) Diagnostic {
+	d.Labels = append(d.Labels[:len(d.Labels):len(d.Labels)], Label{Span: span, Message: fmt.Sprintf(format, args...)})
+	return d
+}
+
+// Copy of the diagnostic with a note added
+func (d Diagnostic) WithNote(format string, args ...interface{}) Diagnostic {
+	d.Notes = append(d.Notes[:len(d.Notes):len(d.Notes)], fmt.Sprintf(format, args...))
+	return d
+}
+
+// Copy of the diagnostic with a suggested fix
+func (d Diagnostic) WithFix(span Span, replacement string, message string) Diagnostic {
+	d.Fix = &Fix{Span: span, Replacement: replacement, Message: message}
+	return d
+}
+
+// Format as position: message, warnings are marked so they are not mistaken for errors
+func (d Diagnostic) String() string {
+	if d.Severity == Warning {
+		return fmt.Sprintf("%s: warning: %s", d.Span.Start, d.Message)
+	}
+	return fmt.Sprintf("%s: %s", d.Span.Start, d.Message)
+}
+
+// A diagnostic can be returned as an error
+func (d Diagnostic) Error() string {
+	return d.String()
+}
+
+// Diagnostics in the order they were found
+type List []Diagnostic
+
+// Verify at least one diagnostic is an error
+func (list List) HasErrors() bool {
+	for _, d := range list {
+		if d.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// Diagnostics of a single severity
+func (list List) Filter(sev Severity) List {
+	out := List{}
+	for _, d := range list {
+		if d.Severity == sev {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// Sort by file, then position, keeping the order of diagnostics at the same position
+func (list List) Sort() {
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i].Span.Start, list[j].Span.Start
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
+
+// String view of each diagnostic
+func (list List) Strings() []string {
+	out := []string{}
+	for _, d := range list {
+		out = append(out, d.String())
+	}
+	return out
+}
+
+// Diagnostic carried by an error, any other error becomes an error diagnostic with no code or span
+func FromError(err error) Diagnostic {
+	var d Diagnostic
+	if errors.As(err, &d) {
+		return d
+	}
+	return Diagnostic{Severity: Error, Message: err.Error()}
+}
@@ -0,0 +1,213 @@
+package diag
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Urvirith/bearlang/src/token"
+)
+
+func pos(line int, column int) token.Position {
+	return token.Position{Line: line, Column: column}
+}
+
+func TestDiagnosticString(t *testing.T) {
+	tests := []struct {
+		diag     Diagnostic
+		expected string
+	}{
+		{Errorf(UnexpectedToken, At(pos(1, 5)), "expected %s", ";"), "1:5: expected ;"},
+		{Warningf(UnreachableArm, At(pos(3, 2)), "unreachable match arm"), "3:2: warning: unreachable match arm"},
+	}
+
+	for i, tt := range tests {
+		if tt.diag.String() != tt.expected {
+			t.Errorf("tests[%d] - string wrong. expected=%q, got=%q", i, tt.expected, tt.diag.String())
+		}
+
+		if tt.diag.Error() != tt.expected {
+			t.Errorf("tests[%d] - error wrong. expected=%q, got=%q", i, tt.expected, tt.diag.Error())
+		}
+	}
+}
+
+func TestDiagnosticBuilders(t *testing.T) {
+	base := Errorf(DuplicateDiscriminant, At(pos(2, 1)), "discriminant 0 of B is already used by A")
+	d := base.WithLabel(At(pos(1, 1)), "%s declared here", "A").WithNote("discriminants must be unique").WithFix(At(pos(2, 1)), "C", "rename")
+
+	if len(base.Labels) != 0 || len(base.Notes) != 0 || base.Fix != nil {
+		t.Errorf("builders changed the original diagnostic. got=%+v", base)
+	}
+
+	if len(d.Labels) != 1 || d.Labels[0].Message != "A declared here" {
+		t.Errorf("labels wrong. got=%+v", d.Labels)
+	}
+
+	if len(d.Notes) != 1 || d.Notes[0] != "discriminants must be unique" {
+		t.Errorf("notes wrong. got=%+v", d.Notes)
+	}
+
+	if d.Fix == nil || d.Fix.Replacement != "C" {
+		t.Errorf("fix wrong. got=%+v", d.Fix)
+	}
+}
+
+func TestList(t *testing.T) {
+	list := List{
+		Warningf(UnreachableArm, At(pos(4, 1)), "d"),
+		Errorf(UnexpectedToken, At(pos(2, 7)), "b"),
+		Errorf(ExpectedExpression, At(pos(2, 3)), "a"),
+		Errorf(ExpectedType, At(pos(2, 7)), "c"),
+	}
+
+	if !list.HasErrors() {
+		t.Errorf("list has errors, HasErrors returned false")
+	}
+
+	warnings := list.Filter(Warning)
+	if len(warnings) != 1 || warnings[0].Message != "d" {
+		t.Errorf("filter wrong. got=%v", warnings.Strings())
+	}
+
+	if warnings.HasErrors() {
+		t.Errorf("warnings only, HasErrors returned true")
+	}
+
+	list.Sort()
+
+	expected := []string{"2:3: a", "2:7: b", "2:7: c", "4:1: warning: d"}
+	got := list.Strings()
+
+	for i, exp := range expected {
+		if got[i] != exp {
+			t.Errorf("tests[%d] - sort wrong. expected=%q, got=%q", i, exp, got[i])
+		}
+	}
+}
+
+func TestFromError(t *testing.T) {
+	d := Errorf(DivisionByZero, At(pos(1, 9)), "division by zero")
+
+	tests := []struct {
+		err      error
+		code     Code
+		expected string
+	}{
+		{d, DivisionByZero, "1:9: division by zero"},
+		{fmt.Errorf("folding X: %w", d), DivisionByZero, "1:9: division by zero"},
+		{errors.New("plain"), "", "-: plain"},
+	}
+
+	for i, tt := range tests {
+		got := FromError(tt.err)
+
+		if got.Code != tt.code {
+			t.Errorf("tests[%d] - code wrong. expected=%q, got=%q", i, tt.code, got.Code)
+		}
+
+		if got.String() != tt.expected {
+			t.Errorf("tests[%d] - string wrong. expected=%q, got=%q", i, tt.expected, got.String())
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	src := "enum Mode {\n\tIdle = 1,\n\tRun = 1,\n}\nlet x = 5"
+
+	tests := []struct {
+		diag     Diagnostic
+		expected string
+	}{
+		{
+			Errorf(UnexpectedToken, At(pos(5, 10)), "expected next rune to be ;, got EOF instead").
+				WithFix(At(pos(5, 10)), ";", "add ; to end the statement"),
+			"error[E0100]: expected next rune to be ;, got EOF instead\n" +
+				" --> 5:10\n" +
+				"  |\n" +
+				"5 | let x = 5\n" +
+				"  |          ^\n" +
+				"  = help: add ; to end the statement\n\n",
+		},
+		{
+			Errorf(DuplicateDiscriminant, Span{pos(3, 2), pos(3, 5)}, "discriminant 1 of Run is already used by Idle").
+				WithLabel(Span{pos(2, 2), pos(2, 6)}, "Idle declared here").
+				WithNote("discriminants must be unique"),
+			"error[E0306]: discriminant 1 of Run is already used by Idle\n" +
+				" --> 3:2\n" +
+				"  |\n" +
+				"2 | \tIdle = 1,\n" +
+				"  | \t---- Idle declared here\n" +
+				"3 | \tRun = 1,\n" +
+				"  | \t^^^\n" +
+				"  = note: discriminants must be unique\n\n",
+		},
+		{
+			Warningf(UnreachableArm, Span{pos(1, 1), pos(1, 5)}, "unused").
+				WithLabel(At(pos(5, 1)), "here"),
+			"warning[W0301]: unused\n" +
+				" --> 1:1\n" +
+				"  |\n" +
+				"1 | enum Mode {\n" +
+				"  | ^^^^\n" +
+				"...\n" +
+				"5 | let x = 5\n" +
+				"  | - here\n\n",
+		},
+		{
+			Errorf("", At(token.Position{}), "missing constant expression"),
+			"error: missing constant expression\n\n",
+		},
+	}
+
+	for i, tt := range tests {
+		var out strings.Builder
+
+		rnd := NewRenderer(&out, src)
+		rnd.Render(List{tt.diag})
+
+		if out.String() != tt.expected {
+			t.Errorf("tests[%d] - render wrong. expected=\n%s\ngot=\n%s", i, tt.expected, out.String())
+		}
+	}
+}
+
+func TestRenderUnicode(t *testing.T) {
+	// Columns count bytes, é takes two of them but one cell on screen
+	src := "let s = \"é\"; x"
+
+	var out strings.Builder
+	rnd := NewRenderer(&out, src)
+	rnd.Render(List{
+		Errorf(UndefinedName, Span{pos(1, 15), pos(1, 16)}, "undefined name x").
+			WithLabel(Span{pos(1, 9), pos(1, 13)}, "string"),
+	})
+
+	expected := "error[E0500]: undefined name x\n" +
+		" --> 1:15\n" +
+		"  |\n" +
+		"1 | let s = \"é\"; x\n" +
+		"  |              ^\n" +
+		"  |         --- string\n\n"
+
+	if out.String() != expected {
+		t.Errorf("render wrong. expected=\n%s\ngot=\n%s", expected, out.String())
+	}
+}
+
+func TestRenderColor(t *testing.T) {
+	var out strings.Builder
+
+	rnd := NewRenderer(&out, "let x = 5")
+	rnd.SetColor(true)
+	rnd.Render(List{Warningf(UnreachableArm, At(pos(1, 5)), "unused")})
+
+	if !strings.HasPrefix(out.String(), colorYellow+"warning[W0301]"+colorReset) {
+		t.Errorf("warning header not yellow. got=%q", out.String())
+	}
+
+	if IsTerminal(&out) {
+		t.Errorf("a strings.Builder is not a terminal")
+	}
+}
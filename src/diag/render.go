@@ -0,0 +1,235 @@
+package diag
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ANSI escapes used when colour is on
+const (
+	colorReset  = "\x1b[0m"
+	colorBold   = "\x1b[1m"
+	colorRed    = "\x1b[1;31m"
+	colorYellow = "\x1b[1;33m"
+	colorBlue   = "\x1b[1;34m"
+)
+
+// Structure defining the Renderer, prints diagnostics with the source lines they point at
+type Renderer struct {
+	out   io.Writer
+	lines []string // Source split into lines, without their line endings
+	color bool
+}
+
+// A span to underline, the primary span has no message of its own
+type mark struct {
+	span    Span
+	message string
+	primary bool
+}
+
+// Create new instance for the source given to the lexer, colour is on when out is a terminal
+func NewRenderer(out io.Writer, src string) *Renderer {
+	lines := strings.Split(src, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	return &Renderer{out: out, lines: lines, color: IsTerminal(out) && os.Getenv("NO_COLOR") == ""}
+}
+
+// Turn colour on or off
+func (rnd *Renderer) SetColor(color bool) {
+	rnd.color = color
+}
+
+// Verify a writer is a terminal, anything that is not a file is not
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Print every diagnostic followed by a blank line
+func (rnd *Renderer) Render(list List) {
+	for _, d := range list {
+		rnd.render(d)
+		fmt.Fprintln(rnd.out)
+	}
+}
+
+// Print a diagnostic as its header, the source lines of its spans and its notes
+//
+//	error[E0100]: expected next rune to be ;, got EOF instead
+//	 --> 1:10
+//	  |
+//	1 | let x = 5
+//	  |          ^
+//	  = help: add ; to end the statement
+func (rnd *Renderer) render(d Diagnostic) {
+	sevColor := colorRed
+	if d.Severity == Warning {
+		sevColor = colorYellow
+	}
+
+	header := d.Severity.String()
+	if d.Code != "" {
+		header += "[" + string(d.Code) + "]"
+	}
+	fmt.Fprintf(rnd.out, "%s: %s\n", rnd.paint(sevColor, header), rnd.paint(colorBold, d.Message))
+
+	marks := []mark{{span: d.Span, primary: true}}
+	for _, l := range d.Labels {
+		marks = append(marks, mark{span: l.Span, message: l.Message})
+	}
+
+	lines := rnd.markedLines(marks)
+	width := 1
+	if len(lines) != 0 {
+		width = len(strconv.Itoa(lines[len(lines)-1]))
+	}
+	gutter := strings.Repeat(" ", width)
+
+	if d.Span.Start.IsValid() || d.Span.Start.Filename != "" {
+		fmt.Fprintf(rnd.out, "%s%s %s\n", gutter, rnd.paint(colorBlue, "-->"), d.Span.Start)
+	}
+
+	if len(lines) != 0 {
+		fmt.Fprintf(rnd.out, "%s %s\n", gutter, rnd.paint(colorBlue, "|"))
+	}
+
+	for i, line := range lines {
+		if i > 0 && line > lines[i-1]+1 {
+			fmt.Fprintln(rnd.out, rnd.paint(colorBlue, "..."))
+		}
+
+		src := rnd.lines[line-1]
+		fmt.Fprintf(rnd.out, "%s %s %s\n", rnd.paint(colorBlue, fmt.Sprintf("%*d", width, line)), rnd.paint(colorBlue, "|"), src)
+
+		for _, m := range marks {
+			if m.span.Start.Line != line {
+				continue
+			}
+
+			underline, markColor := "-", colorBlue
+			if m.primary {
+				underline, markColor = "^", sevColor
+			}
+
+			text := strings.Repeat(underline, markWidth(m.span, src))
+			if m.message != "" {
+				text += " " + m.message
+			}
+
+			fmt.Fprintf(rnd.out, "%s %s %s%s\n", gutter, rnd.paint(colorBlue, "|"), indent(src, m.span.Start.Column), rnd.paint(markColor, text))
+		}
+	}
+
+	for _, note := range d.Notes {
+		fmt.Fprintf(rnd.out, "%s %s note: %s\n", gutter, rnd.paint(colorBlue, "="), note)
+	}
+
+	if d.Fix != nil {
+		help := d.Fix.Message
+		if help == "" {
+			help = fmt.Sprintf("replace with %q", d.Fix.Replacement)
+		}
+		fmt.Fprintf(rnd.out, "%s %s help: %s\n", gutter, rnd.paint(colorBlue, "="), help)
+	}
+}
+
+// Line numbers of the marks that fall within the source, sorted and without duplicates
+func (rnd *Renderer) markedLines(marks []mark) []int {
+	seen := map[int]bool{}
+	lines := []int{}
+
+	for _, m := range marks {
+		line := m.span.Start.Line
+		if line < 1 || line > len(rnd.lines) || seen[line] {
+			continue
+		}
+
+		seen[line] = true
+		lines = append(lines, line)
+	}
+
+	sort.Ints(lines)
+	return lines
+}
+
+// Wrap text in a colour when colour is on
+func (rnd *Renderer) paint(color string, text string) string {
+	if !rnd.color || text == "" {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// Number of characters to underline, spans running past the line stop at its end and empty spans get one
+func markWidth(span Span, src string) int {
+	end := span.End.Column - 1
+	if span.End.Line != span.Start.Line {
+		end = len(src)
+	}
+
+	if width := cells(src, span.Start.Column-1, end); width > 0 {
+		return width
+	}
+	return 1
+}
+
+// Padding up to a column, tabs of the source are kept so the underline lines up
+func indent(src string, column int) string {
+	var out strings.Builder
+
+	for i, r := range src {
+		if i >= column-1 {
+			break
+		}
+
+		if r == '\t' {
+			out.WriteByte('\t')
+		} else {
+			out.WriteByte(' ')
+		}
+	}
+
+	if pad := column - 1 - len(src); pad > 0 {
+		out.WriteString(strings.Repeat(" ", pad))
+	}
+
+	return out.String()
+}
+
+// Characters between two byte offsets of a line, columns count bytes but a character is shown as one cell
+func cells(src string, start int, end int) int {
+	if start < 0 {
+		start = 0
+	}
+
+	n := 0
+	if end > len(src) {
+		// Past the end of the line each column is one cell
+		if start > len(src) {
+			n = end - start
+		} else {
+			n = end - len(src)
+		}
+		end = len(src)
+	}
+
+	if start < end {
+		n += utf8.RuneCountInString(src[start:end])
+	}
+
+	return n
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Urvirith/bearlang/src/diag"
+	"github.com/Urvirith/bearlang/src/format"
+)
+
+// Format source files, bearlang fmt [-w | -check] [files], standard input is read when no file is given.
+// The status is 1 when -check finds a file that is not formatted and 2 on errors
+func formatCommand(args []string, in io.Reader, out io.Writer, errOut io.Writer) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	write := flags.Bool("w", false, "write the result back to the file instead of printing it")
+	check := flags.Bool("check", false, "print a diff of each file that is not formatted, without changing it")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *write && *check {
+		fmt.Fprintln(errOut, "fmt: -w and -check cannot be used together")
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(errOut, "fmt: -w needs a file to write")
+			return 2
+		}
+
+		src, err := io.ReadAll(in)
+		if err != nil {
+			fmt.Fprintf(errOut, "fmt: %s\n", err)
+			return 2
+		}
+
+		return formatFile("<stdin>", src, *write, *check, out, errOut)
+	}
+
+	status := 0
+
+	for _, name := range flags.Args() {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			fmt.Fprintf(errOut, "fmt: %s\n", err)
+			status = 2
+			continue
+		}
+
+		if s := formatFile(name, src, *write, *check, out, errOut); s > status {
+			status = s
+		}
+	}
+
+	return status
+}
+
+// Format a single file, printing the result, writing it back or printing the diff to it
+func formatFile(name string, src []byte, write bool, check bool, out io.Writer, errOut io.Writer) int {
+	res, err := format.Source(name, src)
+
+	var parseErr *format.ParseError
+	if errors.As(err, &parseErr) {
+		diag.NewRenderer(errOut, string(src)).Render(parseErr.Diagnostics)
+		return 2
+	}
+
+	switch {
+	case check:
+		if !bytes.Equal(src, res) {
+			out.Write(format.Diff("a/"+name, "b/"+name, src, res))
+			return 1
+		}
+	case write:
+		if bytes.Equal(src, res) {
+			return 0
+		}
+
+		info, err := os.Stat(name)
+		if err == nil {
+			err = os.WriteFile(name, res, info.Mode().Perm())
+		}
+
+		if err != nil {
+			fmt.Fprintf(errOut, "fmt: %s\n", err)
+			return 2
+		}
+	default:
+		out.Write(res)
+	}
+
+	return 0
+}
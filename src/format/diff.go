@@ -0,0 +1,158 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Unchanged lines shown around each change of a diff
+const diffContext = 3
+
+// A line of a diff, op is ' ' when the line is kept, '-' when removed and '+' when added
+type edit struct {
+	op   byte
+	text string
+}
+
+// Unified diff turning a into b, empty when they are equal
+//
+//	--- a/main.bl
+//	+++ b/main.bl
+//	@@ -1,2 +1,2 @@
+//	-let x=1;
+//	+let x = 1;
+//	 let y = 2;
+func Diff(oldName string, newName string, a []byte, b []byte) []byte {
+	if bytes.Equal(a, b) {
+		return nil
+	}
+
+	edits := diffLines(splitLines(a), splitLines(b))
+
+	// Line of a and of b each edit starts at, counted from 0
+	aLine, bLine := make([]int, len(edits)+1), make([]int, len(edits)+1)
+	for i, e := range edits {
+		aLine[i+1], bLine[i+1] = aLine[i], bLine[i]
+		if e.op != '+' {
+			aLine[i+1]++
+		}
+		if e.op != '-' {
+			bLine[i+1]++
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			i++
+			continue
+		}
+
+		// Changes closer than twice the context share a hunk
+		end := i + 1
+		for j := i + 1; j < len(edits) && j-end < 2*diffContext; j++ {
+			if edits[j].op != ' ' {
+				end = j + 1
+			}
+		}
+
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		stop := end + diffContext
+		if stop > len(edits) {
+			stop = len(edits)
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aLine[start], aLine[stop]), hunkRange(bLine[start], bLine[stop]))
+		for _, e := range edits[start:stop] {
+			out.WriteByte(e.op)
+			out.WriteString(e.text + "\n")
+		}
+
+		i = stop
+	}
+
+	return out.Bytes()
+}
+
+// Start and length of a hunk, an empty hunk starts at the line before it
+func hunkRange(from int, to int) string {
+	if to == from {
+		return fmt.Sprintf("%d,0", from)
+	}
+	return fmt.Sprintf("%d,%d", from+1, to-from)
+}
+
+func splitLines(src []byte) []string {
+	text := strings.TrimSuffix(string(src), "\n")
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(text, "\n")
+}
+
+// Edits turning a into b by their longest common subsequence, the common start and end are set aside first
+func diffLines(a []string, b []string) []edit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := []edit{}
+	for _, text := range a[:prefix] {
+		edits = append(edits, edit{' ', text})
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	// lcs[i][j] is the length of the longest common subsequence of midA[i:] and midB[j:]
+	lcs := make([][]int, len(midA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(midB)+1)
+	}
+
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			switch {
+			case midA[i] == midB[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(midA) || j < len(midB) {
+		switch {
+		case i < len(midA) && j < len(midB) && midA[i] == midB[j]:
+			edits = append(edits, edit{' ', midA[i]})
+			i++
+			j++
+		case j == len(midB) || (i < len(midA) && lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{'-', midA[i]})
+			i++
+		default:
+			edits = append(edits, edit{'+', midB[j]})
+			j++
+		}
+	}
+
+	for _, text := range a[len(a)-suffix:] {
+		edits = append(edits, edit{' ', text})
+	}
+
+	return edits
+}
@@ -0,0 +1,261 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/Urvirith/bearlang/src/ast"
+	"github.com/Urvirith/bearlang/src/parser"
+	"github.com/Urvirith/bearlang/src/token"
+)
+
+// Binding power of each infix operator, as the parser gives it
+var precedences = map[string]int{
+	"||": parser.LOGICOR,
+	"&&": parser.LOGICAND,
+	"==": parser.EQUALS,
+	"!=": parser.EQUALS,
+	"<":  parser.LESSGREATER,
+	">":  parser.LESSGREATER,
+	"<=": parser.LESSGREATER,
+	">=": parser.LESSGREATER,
+	"|":  parser.BITOR,
+	"^":  parser.BITXOR,
+	"&":  parser.BITAND,
+	"<<": parser.SHIFT,
+	">>": parser.SHIFT,
+	"+":  parser.SUM,
+	"-":  parser.SUM,
+	"*":  parser.PRODUCT,
+	"/":  parser.PRODUCT,
+	"%":  parser.PRODUCT,
+}
+
+// Operators that bind differently than in C, mixing them with others keeps its brackets
+var bitwise = map[string]bool{"|": true, "^": true, "&": true, "<<": true, ">>": true}
+
+// Precedence an expression binds at, literals and names bind as tightly as a call
+func precedence(expr ast.Expression) int {
+	switch expr := expr.(type) {
+	case *ast.GroupExpression:
+		return precedence(expr.Expression)
+	case *ast.InfixExpression:
+		return precedences[expr.Operator]
+	case *ast.RangeExpression:
+		return parser.RANGE
+	case *ast.PrefixExpression, *ast.DerefExpression, *ast.AddressOf:
+		return parser.PREFIX
+	}
+	return parser.CALL
+}
+
+// Print an expression on a single line, brackets are only written where they are needed or aid reading
+func exprString(expr ast.Expression) string {
+	switch expr := expr.(type) {
+	case *ast.GroupExpression:
+		return exprString(expr.Expression)
+	case *ast.InfixExpression:
+		return infixOperand(expr.Left, expr.Operator, false) + " " + expr.Operator + " " + infixOperand(expr.Right, expr.Operator, true)
+	case *ast.PrefixExpression:
+		return prefixString(expr.Operator, expr.Right)
+	case *ast.DerefExpression:
+		return prefixString("*", expr.Operand)
+	case *ast.AddressOf:
+		return prefixString("&", expr.Operand)
+	case *ast.CallExpression:
+		args := []string{}
+		for _, arg := range expr.Arguments {
+			args = append(args, exprString(arg))
+		}
+		return operand(expr.Function, parser.CALL) + "(" + strings.Join(args, ", ") + ")"
+	case *ast.IndexExpression:
+		return operand(expr.Left, parser.CALL) + "[" + exprString(expr.Index) + "]"
+	case *ast.FieldExpression:
+		return operand(expr.Left, parser.CALL) + "." + expr.Field.Value
+	case *ast.RangeExpression:
+		return rangeString(expr, parser.RANGE+1)
+	case *ast.MatchExpression:
+		if len(expr.Arms) == 0 {
+			return "match " + exprString(expr.Subject) + " {}"
+		}
+
+		arms := []string{}
+		for _, arm := range expr.Arms {
+			arms = append(arms, patternsString(arm)+" => "+flatStatement(arm.Body, ""))
+		}
+		return "match " + exprString(expr.Subject) + " { " + strings.Join(arms, ", ") + " }"
+	}
+
+	return expr.String()
+}
+
+// Print an operand, in brackets when it binds looser than prec
+func operand(expr ast.Expression, prec int) string {
+	if precedence(expr) < prec {
+		return "(" + exprString(expr) + ")"
+	}
+	return exprString(expr)
+}
+
+// Print a side of an infix expression, a right side of the same precedence keeps its brackets
+func infixOperand(expr ast.Expression, op string, right bool) string {
+	prec := precedences[op]
+	inner, ok := ast.Unparen(expr).(*ast.InfixExpression)
+
+	switch {
+	case precedence(expr) < prec, right && precedence(expr) == prec:
+		return "(" + exprString(expr) + ")"
+	case ok && precedences[inner.Operator] != prec && (bitwise[op] || bitwise[inner.Operator]):
+		return "(" + exprString(expr) + ")"
+	}
+
+	return exprString(expr)
+}
+
+// Print op operand, -(-x) and &(&x) keep their brackets so they do not read as -- and &&
+func prefixString(op string, expr ast.Expression) string {
+	text := operand(expr, parser.PREFIX)
+
+	if (op == "-" || op == "&") && strings.HasPrefix(text, op) {
+		text = "(" + text + ")"
+	}
+
+	return op + text
+}
+
+// Print low..high or low..=high with each end bound at least as tightly as prec
+func rangeString(expr *ast.RangeExpression, prec int) string {
+	op := ".."
+	if expr.Inclusive {
+		op = "..="
+	}
+
+	return operand(expr.Low, prec) + op + operand(expr.High, prec)
+}
+
+// Split a chain of operators of one precedence into its operands and operators, a + b - c is a, b, c and +, -
+func chain(expr *ast.InfixExpression) ([]string, []string) {
+	operands, ops := []string{}, []string{}
+
+	if left, ok := ast.Unparen(expr.Left).(*ast.InfixExpression); ok && precedences[left.Operator] == precedences[expr.Operator] {
+		operands, ops = chain(left)
+	} else {
+		operands = append(operands, infixOperand(expr.Left, expr.Operator, false))
+	}
+
+	return append(operands, infixOperand(expr.Right, expr.Operator, true)), append(ops, expr.Operator)
+}
+
+// Print the patterns of an arm separated by |, patterns only take a prefix expression without brackets
+func patternsString(arm *ast.MatchArm) string {
+	if arm.Default {
+		return "default"
+	}
+
+	patterns := []string{}
+	for _, pattern := range arm.Patterns {
+		if rng, ok := ast.Unparen(pattern).(*ast.RangeExpression); ok {
+			patterns = append(patterns, rangeString(rng, parser.PREFIX))
+		} else {
+			patterns = append(patterns, operand(pattern, parser.PREFIX))
+		}
+	}
+
+	return strings.Join(patterns, " | ")
+}
+
+// Print a statement on a single line, as the body of a match arm suffix is left empty
+func flatStatement(stmt ast.Statement, suffix string) string {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		head := "let " + stmt.Name.Value
+		if stmt.Type != nil {
+			head += ": " + typeString(stmt.Type)
+		}
+		return head + " = " + exprString(stmt.Value) + ";"
+	case *ast.ConstStatement:
+		head := "const " + stmt.Name.Value
+		if stmt.Type != nil {
+			head += ": " + typeString(stmt.Type)
+		}
+		return head + " = " + exprString(stmt.Value) + ";"
+	case *ast.ReturnStatement:
+		if stmt.Value == nil {
+			return "return;"
+		}
+		return "return " + exprString(stmt.Value) + ";"
+	case *ast.AssignStatement:
+		if stmt.Value == nil {
+			return exprString(stmt.Target) + stmt.Token.Literal + suffix
+		}
+		return exprString(stmt.Target) + " " + stmt.Token.Literal + " " + exprString(stmt.Value) + suffix
+	case *ast.ExpressionStatment:
+		return exprString(stmt.Expression) + suffix
+	case *ast.BreakStatement:
+		return branchString(stmt.Token, stmt.Label)
+	case *ast.ContinueStatement:
+		return branchString(stmt.Token, stmt.Label)
+	case *ast.BlockStatement:
+		if len(stmt.Statements) == 0 {
+			return "{}"
+		}
+
+		stmts := []string{}
+		for _, s := range stmt.Statements {
+			stmts = append(stmts, flatStatement(s, ";"))
+		}
+		return "{ " + strings.Join(stmts, " ") + " }"
+	}
+
+	return stmt.String()
+}
+
+// Print a type, the length of an array is an expression
+func typeString(typ ast.TypeExpr) string {
+	switch typ := typ.(type) {
+	case *ast.PointerType:
+		return typeString(typ.Elem) + "*"
+	case *ast.VolatileType:
+		return "vol " + typeString(typ.Elem)
+	case *ast.ArrayType:
+		return "[" + exprString(typ.Len) + "]" + typeString(typ.Elem)
+	}
+
+	return typ.String()
+}
+
+// Print attributes each followed by a space, empty when there are none
+func attributeString(attrs []*ast.Attribute) string {
+	var out strings.Builder
+
+	for _, attr := range attrs {
+		out.WriteString("@" + attr.Name.Value)
+
+		if len(attr.Args) != 0 {
+			args := []string{}
+			for _, arg := range attr.Args {
+				args = append(args, exprString(arg))
+			}
+			out.WriteString("(" + strings.Join(args, ", ") + ")")
+		}
+
+		out.WriteString(" ")
+	}
+
+	return out.String()
+}
+
+// Print break or continue with its label
+func branchString(tok token.Token, label *ast.Identifier) string {
+	if label == nil {
+		return tok.Literal + ";"
+	}
+	return tok.Literal + " " + label.Value + ";"
+}
+
+// Print the label in front of a loop, empty when it has none
+func labelString(label *ast.Identifier) string {
+	if label == nil {
+		return ""
+	}
+	return label.Value + ": "
+}
@@ -0,0 +1,625 @@
+package format
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Urvirith/bearlang/src/ast"
+	"github.com/Urvirith/bearlang/src/diag"
+	"github.com/Urvirith/bearlang/src/lexer"
+	"github.com/Urvirith/bearlang/src/parser"
+	"github.com/Urvirith/bearlang/src/token"
+)
+
+// Lines longer than Width are wrapped where the code allows it
+const Width = 100
+
+// Spaces per level of indentation
+const indentWidth = 4
+
+// Error returned when the source does not parse, nothing is formatted
+type ParseError struct {
+	Diagnostics diag.List
+}
+
+func (err *ParseError) Error() string {
+	return strings.Join(err.Diagnostics.Strings(), "\n")
+}
+
+// Parse src and print it in canonical form, formatting the result again gives it back unchanged
+func Source(filename string, src []byte) ([]byte, error) {
+	lex := lexer.NewFile(filename, string(src))
+	lex.EmitComments(true)
+
+	psr := parser.New(lex)
+	prg := psr.ParseProgram()
+
+	if diags := psr.Diagnostics(); diags.HasErrors() {
+		return nil, &ParseError{Diagnostics: diags}
+	}
+
+	return Program(prg), nil
+}
+
+// Print a program in canonical form, the comments are placed by their positions
+func Program(prg *ast.Program) []byte {
+	p := &printer{comments: prg.Comments}
+
+	p.statements(prg.Statements, math.MaxInt)
+	p.leading(math.MaxInt)
+
+	return p.bytes()
+}
+
+// Structure defining the printer, code is collected as lines and laid out once complete
+type printer struct {
+	lines    []line
+	comments []*ast.Comment // Comments of the program in source order
+	next     int            // Index of the first comment not yet printed
+	indent   int            // Current level of indentation
+	last     int            // Source line of what was printed last, 0 at the start of a block
+}
+
+// A line of output, a blank line has neither code nor comment
+type line struct {
+	indent  int
+	cells   []string // Code, split where the columns of neighbouring lines line up
+	comment string   // Trailing comment, or a comment standing alone when there are no cells
+}
+
+// Add a line of code at the current indentation
+func (p *printer) code(cells ...string) {
+	p.lines = append(p.lines, line{indent: p.indent, cells: cells})
+}
+
+// Add a blank line when the source had one between what was printed last and line
+func (p *printer) gap(srcLine int) {
+	if p.last > 0 && srcLine > p.last+1 {
+		p.lines = append(p.lines, line{})
+	}
+}
+
+// Print the comments before the offset on lines of their own
+func (p *printer) leading(offset int) {
+	for p.next < len(p.comments) && p.comments[p.next].Pos().Offset < offset {
+		cm := p.comments[p.next]
+		p.next++
+
+		p.gap(cm.Pos().Line)
+		p.lines = append(p.lines, line{indent: p.indent, comment: cm.Text})
+		p.last = cm.End().Line
+	}
+}
+
+// Move the comments that start on the source line, before the offset, to the end of the last line printed
+func (p *printer) trailing(srcLine int, offset int) {
+	p.trailingAt(len(p.lines)-1, srcLine, offset)
+}
+
+// Move the comments that start on the source line, before the offset, to the end of the printed line at index
+func (p *printer) trailingAt(index int, srcLine int, offset int) {
+	for p.next < len(p.comments) {
+		cm := p.comments[p.next]
+		if cm.Pos().Line != srcLine || cm.Pos().Offset >= offset || index < 0 {
+			return
+		}
+		p.next++
+
+		ln := &p.lines[index]
+		if ln.comment != "" {
+			ln.comment += " "
+		}
+		ln.comment += cm.Text
+		p.last = cm.End().Line
+	}
+}
+
+// Print the statements of a block or program, end is the offset the block closes at
+func (p *printer) statements(stmts []ast.Statement, end int) {
+	for i, stmt := range stmts {
+		next := end
+		if i+1 < len(stmts) {
+			next = stmts[i+1].Pos().Offset
+		}
+
+		// Comments inside a statement printed on one line are moved in front of it
+		if compound(stmt) {
+			p.leading(stmt.Pos().Offset)
+		} else {
+			p.leading(stmt.End().Offset)
+		}
+
+		p.gap(stmt.Pos().Line)
+		p.statement(stmt)
+		p.last = stmt.End().Line
+		p.trailing(stmt.End().Line, next)
+	}
+}
+
+// Verify a statement is printed over several lines, holding blocks or a match of its own
+func compound(stmt ast.Statement) bool {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		return isMatch(stmt.Value)
+	case *ast.ConstStatement:
+		return isMatch(stmt.Value)
+	case *ast.ReturnStatement:
+		return isMatch(stmt.Value)
+	case *ast.AssignStatement:
+		return isMatch(stmt.Value)
+	case *ast.ExpressionStatment:
+		return isMatch(stmt.Expression)
+	case *ast.BreakStatement, *ast.ContinueStatement:
+		return false
+	}
+	return true
+}
+
+func isMatch(expr ast.Expression) bool {
+	_, ok := ast.Unparen(expr).(*ast.MatchExpression)
+	return ok
+}
+
+// Print a block, open is the text in front of the { and close the text after the }
+func (p *printer) block(open string, blk *ast.BlockStatement, close string) {
+	if len(blk.Statements) == 0 && !p.commentBefore(blk.Rbrace.Pos.Offset) {
+		p.code(open + "{}" + close)
+		return
+	}
+
+	p.code(open + "{")
+	p.blockBody(blk)
+	p.code("}" + close)
+}
+
+// Print the statements of a block one level in
+func (p *printer) blockBody(blk *ast.BlockStatement) {
+	first := blk.Rbrace.Pos.Offset
+	if len(blk.Statements) != 0 {
+		first = blk.Statements[0].Pos().Offset
+	}
+
+	p.body(blk.Token.Pos.Line, first, blk.Rbrace.Pos.Offset, func() {
+		p.statements(blk.Statements, blk.Rbrace.Pos.Offset)
+	})
+}
+
+// Print the inside of braces one level in, the { is on srcLine, the first item inside at offset first and the } at offset end
+func (p *printer) body(srcLine int, first int, end int, inner func()) {
+	// A comment straight after the { stays on its line
+	p.trailing(srcLine, first)
+
+	p.indent++
+	p.last = 0
+	inner()
+	p.leading(end)
+	p.indent--
+}
+
+// Verify an unprinted comment comes before the offset
+func (p *printer) commentBefore(offset int) bool {
+	return p.next < len(p.comments) && p.comments[p.next].Pos().Offset < offset
+}
+
+func (p *printer) statement(stmt ast.Statement) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		head := "let " + stmt.Name.Value
+		if stmt.Type != nil {
+			head += ": " + typeString(stmt.Type)
+		}
+		p.exprLine([]string{head + " = "}, stmt.Value, ";")
+	case *ast.ConstStatement:
+		// The name and the rest form two cells, so runs of constants line up their types
+		if stmt.Type != nil {
+			p.exprLine([]string{"const " + stmt.Name.Value + ":", typeString(stmt.Type) + " = "}, stmt.Value, ";")
+		} else {
+			p.exprLine([]string{"const " + stmt.Name.Value, "= "}, stmt.Value, ";")
+		}
+	case *ast.ReturnStatement:
+		if stmt.Value == nil {
+			p.code("return;")
+		} else {
+			p.exprLine([]string{"return "}, stmt.Value, ";")
+		}
+	case *ast.AssignStatement:
+		p.assign("", stmt, ";")
+	case *ast.ExpressionStatment:
+		p.exprLine([]string{""}, stmt.Expression, ";")
+	case *ast.BreakStatement:
+		p.code(branchString(stmt.Token, stmt.Label))
+	case *ast.ContinueStatement:
+		p.code(branchString(stmt.Token, stmt.Label))
+	case *ast.BlockStatement:
+		p.block("", stmt, "")
+	case *ast.FunctionDecl:
+		p.function(stmt)
+	case *ast.IfStatement:
+		p.ifStatement(stmt)
+	case *ast.LoopStatement:
+		p.block(labelString(stmt.Label)+"loop ", stmt.Body, "")
+	case *ast.WhileStatement:
+		p.exprBlock(labelString(stmt.Label)+"while ", stmt.Condition, stmt.Body)
+	case *ast.ForStatement:
+		head := labelString(stmt.Label) + "for " + stmt.Var.Value
+		if stmt.VarType != nil {
+			head += ": " + typeString(stmt.VarType)
+		}
+		p.exprBlock(head+" in ", stmt.Iterable, stmt.Body)
+	case *ast.StructDecl:
+		p.fields(attributeString(stmt.Attributes)+"struct "+stmt.Name.Value+" ", stmt.Name, stmt.Fields, stmt.Rbrace)
+	case *ast.UnionDecl:
+		p.fields(attributeString(stmt.Attributes)+"union "+stmt.Name.Value+" ", stmt.Name, stmt.Fields, stmt.Rbrace)
+	case *ast.EnumDecl:
+		p.enum(stmt)
+	default:
+		p.code(stmt.String())
+	}
+}
+
+// Print an assignment after head, the suffix ends it with ; or , inside a match arm
+func (p *printer) assign(head string, stmt *ast.AssignStatement, suffix string) {
+	target := head + exprString(stmt.Target)
+
+	if stmt.Value == nil {
+		p.code(target + stmt.Token.Literal + suffix)
+		return
+	}
+
+	p.exprLine([]string{target + " " + stmt.Token.Literal + " "}, stmt.Value, suffix)
+}
+
+// Print head, an expression and a block, wrapping the expression when the line holding the { is too long
+func (p *printer) exprBlock(head string, expr ast.Expression, blk *ast.BlockStatement) {
+	if open := head + exprString(expr) + " "; p.fits(open + "{") {
+		p.block(open, blk, "")
+		return
+	}
+
+	p.wrap([]string{head}, expr, " {")
+	p.blockBody(blk)
+	p.code("}")
+}
+
+// Print [ext] fn name(params) [-> type] { ... }, the parameters go one per line when they do not fit or
+// when comments are among them
+func (p *printer) function(decl *ast.FunctionDecl) {
+	head := "fn " + decl.Name.Value
+	if decl.IsExtern() {
+		head = decl.Linkage.Literal + " " + head
+	}
+
+	tail := ") "
+	if decl.ReturnType != nil {
+		tail = ") -> " + typeString(decl.ReturnType) + " "
+	}
+
+	params := []string{}
+	for _, param := range decl.Params {
+		params = append(params, param.Name.Value+": "+typeString(param.Type))
+	}
+
+	flat := head + "(" + strings.Join(params, ", ") + tail + "{"
+	if len(params) == 0 || p.fits(flat) && !p.commentBefore(decl.Body.Token.Pos.Offset) {
+		p.block(head+"("+strings.Join(params, ", ")+tail, decl.Body, "")
+		return
+	}
+
+	p.code(head + "(")
+	p.indent++
+	for i, param := range decl.Params {
+		next := decl.Body.Token.Pos.Offset
+		if i+1 < len(decl.Params) {
+			next = decl.Params[i+1].Pos().Offset
+		}
+
+		p.item(param, next, func() {
+			p.code(params[i] + ",")
+		})
+	}
+	p.indent--
+	p.block(tail, decl.Body, "")
+}
+
+// Print an if and its chain of elif and else, each } shares its line with what follows it and keeps the
+// comments after it
+func (p *printer) ifStatement(stmt *ast.IfStatement) {
+	p.wrap([]string{"if "}, stmt.Condition, " {")
+
+	for {
+		blk := stmt.Consequence
+		p.blockBody(blk)
+
+		switch alt := stmt.Alternative.(type) {
+		case *ast.IfStatement:
+			at := len(p.lines)
+			p.wrap([]string{"} elif "}, alt.Condition, " {")
+			p.trailingAt(at, blk.Rbrace.Pos.Line, alt.Pos().Offset)
+			stmt = alt
+			continue
+		case *ast.BlockStatement:
+			p.code("} else {")
+			p.trailing(blk.Rbrace.Pos.Line, alt.Pos().Offset)
+			p.blockBody(alt)
+		}
+
+		p.code("}")
+		return
+	}
+}
+
+// Print the fields of a struct or union one per line, the types of neighbouring fields line up
+func (p *printer) fields(open string, name *ast.Identifier, fields []*ast.Field, rbrace token.Token) {
+	if len(fields) == 0 && !p.commentBefore(rbrace.Pos.Offset) {
+		p.code(open + "{}")
+		return
+	}
+
+	p.code(open + "{")
+	first := rbrace.Pos.Offset
+	if len(fields) != 0 {
+		first = fields[0].Pos().Offset
+	}
+
+	p.body(name.End().Line, first, rbrace.Pos.Offset, func() {
+		for i, field := range fields {
+			next := rbrace.Pos.Offset
+			if i+1 < len(fields) {
+				next = fields[i+1].Pos().Offset
+			}
+
+			p.item(field, next, func() {
+				p.code(field.Name.Value+":", typeString(field.Type)+",")
+			})
+		}
+	})
+	p.code("}")
+}
+
+// Print enum Name[: type] { ... }, one variant per line
+func (p *printer) enum(decl *ast.EnumDecl) {
+	open := attributeString(decl.Attributes) + "enum " + decl.Name.Value + " "
+	if decl.Backing != nil {
+		open = attributeString(decl.Attributes) + "enum " + decl.Name.Value + ": " + typeString(decl.Backing) + " "
+	}
+
+	if len(decl.Variants) == 0 && !p.commentBefore(decl.Rbrace.Pos.Offset) {
+		p.code(open + "{}")
+		return
+	}
+
+	p.code(open + "{")
+	first := decl.Rbrace.Pos.Offset
+	if len(decl.Variants) != 0 {
+		first = decl.Variants[0].Pos().Offset
+	}
+
+	p.body(decl.Name.End().Line, first, decl.Rbrace.Pos.Offset, func() {
+		for i, variant := range decl.Variants {
+			next := decl.Rbrace.Pos.Offset
+			if i+1 < len(decl.Variants) {
+				next = decl.Variants[i+1].Pos().Offset
+			}
+
+			p.item(variant, next, func() {
+				if variant.Value == nil {
+					p.code(variant.Name.Value + ",")
+				} else {
+					p.code(variant.Name.Value, "= "+exprString(variant.Value)+",")
+				}
+			})
+		}
+	})
+	p.code("}")
+}
+
+// Print a field, variant or match arm with the comments around it, next is the offset of what follows
+func (p *printer) item(node ast.Node, next int, print func()) {
+	p.leading(node.Pos().Offset)
+	p.gap(node.Pos().Line)
+	print()
+	p.last = node.End().Line
+	p.trailing(node.End().Line, next)
+}
+
+// Print cells followed by an expression and the suffix, wrapping the expression when the line is too long
+func (p *printer) exprLine(cells []string, expr ast.Expression, suffix string) {
+	expr = ast.Unparen(expr)
+
+	if match, ok := expr.(*ast.MatchExpression); ok {
+		p.match(cells, match, suffix)
+		return
+	}
+
+	p.wrap(cells, expr, suffix)
+}
+
+// Print cells followed by an expression and the suffix, a call too long for the line takes one argument per
+// line and a chain of operators or a range breaks after each operator
+func (p *printer) wrap(cells []string, expr ast.Expression, suffix string) {
+	head := strings.Join(cells, " ")
+	last := len(cells) - 1
+	expr = ast.Unparen(expr)
+
+	flat := exprString(expr)
+	if p.fits(head + flat + suffix) {
+		cells[last] += flat + suffix
+		p.code(cells...)
+		return
+	}
+
+	switch expr := expr.(type) {
+	case *ast.CallExpression:
+		if len(expr.Arguments) == 0 {
+			break
+		}
+
+		// One argument per line, the trailing comma keeps the list easy to extend
+		cells[last] += operand(expr.Function, parser.CALL) + "("
+		p.code(cells...)
+		p.indent++
+		for _, arg := range expr.Arguments {
+			p.code(exprString(arg) + ",")
+		}
+		p.indent--
+		p.code(")" + suffix)
+		return
+	case *ast.InfixExpression:
+		// A chain of the same operator breaks after each operator
+		operands, ops := chain(expr)
+
+		cells[last] += operands[0] + " " + ops[0]
+		p.code(cells...)
+		p.indent++
+		for i := 1; i < len(ops); i++ {
+			p.code(operands[i] + " " + ops[i])
+		}
+		p.code(operands[len(operands)-1] + suffix)
+		p.indent--
+		return
+	case *ast.RangeExpression:
+		op := ".."
+		if expr.Inclusive {
+			op = "..="
+		}
+
+		cells[last] += operand(expr.Low, parser.RANGE+1) + op
+		p.code(cells...)
+		p.indent++
+		p.code(operand(expr.High, parser.RANGE+1) + suffix)
+		p.indent--
+		return
+	}
+
+	cells[last] += flat + suffix
+	p.code(cells...)
+}
+
+// Print a match with one arm per line
+func (p *printer) match(cells []string, expr *ast.MatchExpression, suffix string) {
+	cells[len(cells)-1] += "match " + exprString(expr.Subject) + " "
+
+	if len(expr.Arms) == 0 && !p.commentBefore(expr.Rbrace.Pos.Offset) {
+		cells[len(cells)-1] += "{}" + suffix
+		p.code(cells...)
+		return
+	}
+
+	cells[len(cells)-1] += "{"
+	p.code(cells...)
+	first := expr.Rbrace.Pos.Offset
+	if len(expr.Arms) != 0 {
+		first = expr.Arms[0].Pos().Offset
+	}
+
+	p.body(expr.Subject.End().Line, first, expr.Rbrace.Pos.Offset, func() {
+		for i, arm := range expr.Arms {
+			next := expr.Rbrace.Pos.Offset
+			if i+1 < len(expr.Arms) {
+				next = expr.Arms[i+1].Pos().Offset
+			}
+
+			p.item(arm, next, func() {
+				p.arm(arm)
+			})
+		}
+	})
+	p.code("}" + suffix)
+}
+
+// Print patterns => body, a block body needs no comma after it
+func (p *printer) arm(arm *ast.MatchArm) {
+	head := patternsString(arm) + " => "
+
+	switch body := arm.Body.(type) {
+	case *ast.BlockStatement:
+		p.block(head, body, "")
+	case *ast.AssignStatement:
+		p.assign(head, body, ",")
+	case *ast.ExpressionStatment:
+		p.exprLine([]string{head}, body.Expression, ",")
+	default:
+		p.code(head + body.String() + ",")
+	}
+}
+
+// Verify text fits within Width at the current indentation
+func (p *printer) fits(text string) bool {
+	return p.indent*indentWidth+utf8.RuneCountInString(text) <= Width
+}
+
+// Lay out the lines, lining up the cells of runs of lines and the trailing comments of runs of lines
+func (p *printer) bytes() []byte {
+	text := make([]string, len(p.lines))
+
+	for i := 0; i < len(p.lines); {
+		j := i
+		width := 0
+		for j < len(p.lines) && len(p.lines[j].cells) > 1 && p.lines[j].indent == p.lines[i].indent {
+			if w := utf8.RuneCountInString(p.lines[j].cells[0]); w > width {
+				width = w
+			}
+			j++
+		}
+
+		if j == i {
+			text[i] = strings.Join(p.lines[i].cells, " ")
+			i++
+			continue
+		}
+
+		for ; i < j; i++ {
+			cells := p.lines[i].cells
+			text[i] = pad(cells[0], width+1) + strings.Join(cells[1:], " ")
+		}
+	}
+
+	var out bytes.Buffer
+
+	for i := 0; i < len(p.lines); {
+		j := i
+		width := 0
+		for j < len(p.lines) && text[j] != "" && p.lines[j].comment != "" && p.lines[j].indent == p.lines[i].indent {
+			if w := utf8.RuneCountInString(text[j]); w > width {
+				width = w
+			}
+			j++
+		}
+
+		if j == i {
+			writeLine(&out, p.lines[i], text[i], "")
+			i++
+			continue
+		}
+
+		for ; i < j; i++ {
+			writeLine(&out, p.lines[i], pad(text[i], width+1), p.lines[i].comment)
+		}
+	}
+
+	return out.Bytes()
+}
+
+func writeLine(out *bytes.Buffer, ln line, code string, comment string) {
+	if code == "" && ln.comment == "" {
+		out.WriteString("\n")
+		return
+	}
+
+	out.WriteString(strings.Repeat(" ", ln.indent*indentWidth))
+	if code == "" {
+		comment = ln.comment
+	}
+	out.WriteString(code + comment)
+	out.WriteString("\n")
+}
+
+// Pad text with spaces up to width characters
+func pad(text string, width int) string {
+	if n := width - utf8.RuneCountInString(text); n > 0 {
+		return text + strings.Repeat(" ", n)
+	}
+	return text
+}
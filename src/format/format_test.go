@@ -0,0 +1,170 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x=5;", "let x = 5;\n"},
+		{"let   y : u32=(1+2);", "let y: u32 = 1 + 2;\n"},
+		{
+			"const A: u32 = 1; /* one */\nconst LONGER: vol u32* = 2; // two\n\n\n\nconst C = 3;",
+			"const A:      u32 = 1;      /* one */\nconst LONGER: vol u32* = 2; // two\n\nconst C = 3;\n",
+		},
+		{"// leading\n\n\nlet x = 1;\n// closing", "// leading\n\nlet x = 1;\n// closing\n"},
+		{"let x = f(a, /* inside */ b);", "/* inside */\nlet x = f(a, b);\n"},
+		{"let x = a * (b + c) - (d - e) + -(-f);", "let x = a * (b + c) - (d - e) + -(-f);\n"},
+		{"let x = (a << 2) | b & c == 0;", "let x = ((a << 2) | (b & c)) == 0;\n"},
+		{"let x = (*p).y[0] + &a.b;", "let x = (*p).y[0] + &a.b;\n"},
+		{
+			"fn f(a: u8) -> u8 { if a > 1 { return a; } elif a == 1 { return 0; } else { loop { break; } } }",
+			"fn f(a: u8) -> u8 {\n    if a > 1 {\n        return a;\n    } elif a == 1 {\n        return 0;\n    } else {\n        loop {\n            break;\n        }\n    }\n}\n",
+		},
+		{"fn f() { }\nouter: for i: u8 in 0..=9 { continue outer; }", "fn f() {}\nouter: for i: u8 in 0..=9 {\n    continue outer;\n}\n"},
+		{
+			"ext fn g() { // header\n  x++;   // bump\n  y = 2;\n}",
+			"ext fn g() { // header\n    x++; // bump\n    y = 2;\n}\n",
+		},
+		{
+			"@packed struct P { x: u8, longer: u16 /* y */ }\nenum E: u8 { A, B = 4 }\nunion U {}",
+			"@packed struct P {\n    x:      u8,\n    longer: u16, /* y */\n}\nenum E: u8 {\n    A,\n    B = 4,\n}\nunion U {}\n",
+		},
+		{
+			"let y = match x { 0 | 1 => a, 2..=5 => { b = 1; }, default => c += 1 };",
+			"let y = match x {\n    0 | 1 => a,\n    2..=5 => {\n        b = 1;\n    }\n    default => c += 1,\n};\n",
+		},
+		{
+			"let y = f(match x { 0 => 1, default => { g(); } });",
+			"let y = f(match x { 0 => 1, default => { g(); } });\n",
+		},
+		{
+			"let value: u32 = a_function_with_a_long_name(first_argument, second_argument, third_argument, fourth_argument);",
+			"let value: u32 = a_function_with_a_long_name(\n    first_argument,\n    second_argument,\n    third_argument,\n    fourth_argument,\n);\n",
+		},
+		{
+			"const MASK: u32 = FIRST_REGISTER_FLAG | SECOND_REGISTER_FLAG | THIRD_REGISTER_FLAG | FOURTH_REGISTER_FLAG;",
+			"const MASK: u32 = FIRST_REGISTER_FLAG |\n    SECOND_REGISTER_FLAG |\n    THIRD_REGISTER_FLAG |\n    FOURTH_REGISTER_FLAG;\n",
+		},
+		{
+			"fn configure(first_parameter: u32, second_parameter: u32, third_parameter: u32, fourth: u32) -> bool { return true; }",
+			"fn configure(\n    first_parameter: u32,\n    second_parameter: u32,\n    third_parameter: u32,\n    fourth: u32,\n) -> bool {\n    return true;\n}\n",
+		},
+		{"fn f(a: u32, /* c1 */ b: u32) { }", "fn f(\n    a: u32, /* c1 */\n    b: u32,\n) {}\n"},
+		{
+			"if x == 1 { } // after if\nelse { y(); }",
+			"if x == 1 {\n} else { // after if\n    y();\n}\n",
+		},
+		{
+			"if x == 1 { a(); } // after if\nelif y { }",
+			"if x == 1 {\n    a();\n} elif y { // after if\n}\n",
+		},
+		{
+			"if first_condition_value == 1 && second_condition_value == 2 && third_condition_value == 3 && fourth == 4 { a(); }",
+			"if first_condition_value == 1 &&\n    second_condition_value == 2 &&\n    third_condition_value == 3 &&\n    fourth == 4 {\n    a();\n}\n",
+		},
+		{
+			"for index: u32 in FIRST_REGISTER_ADDRESS_OFFSET_VALUE..SECOND_REGISTER_ADDRESS_OFFSET_VALUE_UPPER_LIMIT { a(); }",
+			"for index: u32 in FIRST_REGISTER_ADDRESS_OFFSET_VALUE..\n    SECOND_REGISTER_ADDRESS_OFFSET_VALUE_UPPER_LIMIT {\n    a();\n}\n",
+		},
+	}
+
+	for i, tt := range tests {
+		out, err := Source("", []byte(tt.input))
+		if err != nil {
+			t.Fatalf("tests[%d] - Source returned error: %s", i, err)
+		}
+
+		if string(out) != tt.expected {
+			t.Errorf("tests[%d] - wrong output.\nexpected=%q\ngot=     %q", i, tt.expected, string(out))
+		}
+
+		again, err := Source("", out)
+		if err != nil || !bytes.Equal(again, out) {
+			t.Errorf("tests[%d] - formatting is not idempotent, err=%v\ngot=%q", i, err, string(again))
+		}
+	}
+}
+
+func TestSourceErrors(t *testing.T) {
+	_, err := Source("bad.bl", []byte("let x = ;"))
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected a *ParseError, got %T (%v)", err, err)
+	}
+
+	if len(parseErr.Diagnostics) == 0 || !strings.HasPrefix(err.Error(), "bad.bl:1:9: ") {
+		t.Errorf("wrong error. got=%q", err.Error())
+	}
+}
+
+func TestGolden(t *testing.T) {
+	src, err := os.ReadFile("../../test/main.bl")
+	if err != nil {
+		t.Fatalf("reading test/main.bl: %s", err)
+	}
+
+	out, err := Source("main.bl", src)
+	if err != nil {
+		t.Fatalf("Source returned error: %s", err)
+	}
+
+	if *update {
+		if err := os.WriteFile("testdata/main.bl", out, 0644); err != nil {
+			t.Fatalf("writing testdata/main.bl: %s", err)
+		}
+	}
+
+	expected, err := os.ReadFile("testdata/main.bl")
+	if err != nil {
+		t.Fatalf("reading testdata/main.bl: %s, run go test -update to create it", err)
+	}
+
+	if !bytes.Equal(out, expected) {
+		t.Errorf("testdata/main.bl does not match, run go test -update if the change is intended")
+	}
+
+	again, err := Source("main.bl", out)
+	if err != nil || !bytes.Equal(again, out) {
+		t.Errorf("formatting test/main.bl is not idempotent, err=%v", err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected string
+	}{
+		{"let x = 1;\n", "let x = 1;\n", ""},
+		{
+			"let x=1;\nlet y = 2;\n",
+			"let x = 1;\nlet y = 2;\n",
+			"--- a\n+++ b\n@@ -1,2 +1,2 @@\n-let x=1;\n+let x = 1;\n let y = 2;\n",
+		},
+		{
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			"1\nTWO\n3\n4\n5\n6\n7\n8\n9\n10\n12\n",
+			"--- a\n+++ b\n@@ -1,5 +1,5 @@\n 1\n-2\n+TWO\n 3\n 4\n 5\n@@ -8,5 +8,4 @@\n 8\n 9\n 10\n-11\n 12\n",
+		},
+		{"", "a\n", "--- a\n+++ b\n@@ -0,0 +1,1 @@\n+a\n"},
+	}
+
+	for i, tt := range tests {
+		diff := Diff("a", "b", []byte(tt.a), []byte(tt.b))
+		if string(diff) != tt.expected {
+			t.Errorf("tests[%d] - wrong diff.\nexpected=%q\ngot=     %q", i, tt.expected, string(diff))
+		}
+	}
+}
@@ -0,0 +1,390 @@
+package layout
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/Urvirith/bearlang/src/ast"
+	"github.com/Urvirith/bearlang/src/consteval"
+	"github.com/Urvirith/bearlang/src/diag"
+	"github.com/Urvirith/bearlang/src/token"
+)
+
+// Placement of a single field
+type Field struct {
+	Name   string
+	Offset int64
+	Size   int64
+	Align  int64
+}
+
+// Memory layout of a struct, union or enum
+type Layout struct {
+	Name   string
+	Size   int64
+	Align  int64
+	Fields []Field // Empty for enums
+}
+
+func (lay Layout) String() string {
+	var out strings.Builder
+
+	fmt.Fprintf(&out, "%s size=%d align=%d", lay.Name, lay.Size, lay.Align)
+
+	for _, f := range lay.Fields {
+		fmt.Fprintf(&out, "\n  %s offset=%d size=%d align=%d", f.Name, f.Offset, f.Size, f.Align)
+	}
+
+	return out.String()
+}
+
+// Structure defining the Calculator
+type Calculator struct {
+	ptrSize int64 // Size and alignment of a pointer on the target
+	decls   map[string]ast.Statement
+	order   []string             // Declaration names in source order
+	consts  *consteval.Evaluator // Folds array lengths and attribute values
+	layouts map[string]Layout
+	active  map[string]bool // Declarations being laid out, used to find types that contain themselves
+	failed  map[string]bool // Declarations with errors, so each error is only reported once
+	diags   diag.List
+}
+
+// Create new instance for a program, ptrSize is the size of a pointer on the target in bytes
+func New(prg *ast.Program, ptrSize int64) *Calculator {
+	calc := &Calculator{
+		ptrSize: ptrSize,
+		decls:   make(map[string]ast.Statement),
+		consts:  consteval.New(prg),
+		layouts: make(map[string]Layout),
+		active:  make(map[string]bool),
+		failed:  make(map[string]bool),
+	}
+
+	for _, stmt := range prg.Statements {
+		// A name declared twice is reported by the resolver, only the first declaration is laid out
+		name := declName(stmt)
+		if _, ok := calc.decls[name]; ok || name == "" {
+			continue
+		}
+
+		calc.decls[name] = stmt
+		calc.order = append(calc.order, name)
+	}
+
+	return calc
+}
+
+// Lay out every struct, union and enum of the program, in source order
+func (calc *Calculator) Calculate() []Layout {
+	layouts := []Layout{}
+
+	for _, name := range calc.order {
+		if lay, ok := calc.layoutOf(name, token.Position{}); ok {
+			layouts = append(layouts, lay)
+		}
+	}
+
+	return layouts
+}
+
+// Return errors found while laying out
+func (calc *Calculator) Errors() []string {
+	return calc.diags.Strings()
+}
+
+// Return errors found while laying out with their codes and spans
+func (calc *Calculator) Diagnostics() diag.List {
+	return calc.diags
+}
+
+// Lay out a declaration by name, pos is where it is used for errors
+func (calc *Calculator) layoutOf(name string, pos token.Position) (Layout, bool) {
+	if lay, ok := calc.layouts[name]; ok {
+		return lay, true
+	}
+
+	if calc.failed[name] {
+		return Layout{}, false
+	}
+
+	decl, ok := calc.decls[name]
+	if !ok {
+		calc.errorf(diag.UnknownType, diag.At(pos), "unknown type %s", name)
+		return Layout{}, false
+	}
+
+	if calc.active[name] {
+		calc.errorf(diag.RecursiveType, diag.At(pos), "type %s contains itself, use a pointer", name)
+		return Layout{}, false
+	}
+
+	calc.active[name] = true
+	defer delete(calc.active, name)
+
+	var lay Layout
+
+	switch node := decl.(type) {
+	case *ast.StructDecl:
+		lay, ok = calc.layoutFields(node.Name, node.Attributes, node.Fields, false)
+	case *ast.UnionDecl:
+		lay, ok = calc.layoutFields(node.Name, node.Attributes, node.Fields, true)
+	case *ast.EnumDecl:
+		lay, ok = calc.layoutEnum(node)
+	}
+
+	if ok {
+		calc.layouts[name] = lay
+	} else {
+		calc.failed[name] = true
+	}
+
+	return lay, ok
+}
+
+// Lay out the fields of a struct in order, or of a union all at offset 0
+func (calc *Calculator) layoutFields(ident *ast.Identifier, attrs []*ast.Attribute, fields []*ast.Field, union bool) (Layout, bool) {
+	packed, align, alignPos, ok := calc.attributes(attrs)
+	if !ok {
+		return Layout{}, false
+	}
+
+	name := ident.Value
+	lay := Layout{Name: name, Align: 1}
+	offset := int64(0)
+
+	for _, f := range fields {
+		size, fieldAlign, ok := calc.sizeOf(f.Type)
+		if !ok {
+			return Layout{}, false
+		}
+
+		// Packed fields follow each other with no padding
+		if packed {
+			fieldAlign = 1
+		}
+
+		if union {
+			offset = 0
+		} else if offset, ok = calc.alignUp(offset, fieldAlign); !ok {
+			calc.tooLarge(f.Name.Token, name)
+			return Layout{}, false
+		}
+
+		lay.Fields = append(lay.Fields, Field{Name: f.Name.Value, Offset: offset, Size: size, Align: fieldAlign})
+
+		if fieldAlign > lay.Align {
+			lay.Align = fieldAlign
+		}
+
+		if union {
+			if size > lay.Size {
+				lay.Size = size
+			}
+		} else {
+			if offset, ok = calc.add(offset, size); !ok {
+				calc.tooLarge(f.Name.Token, name)
+				return Layout{}, false
+			}
+			lay.Size = offset
+		}
+	}
+
+	if align != 0 {
+		if align < lay.Align {
+			calc.errorf(diag.InvalidAlign, diag.At(alignPos), "@align(%d) on %s is below its natural alignment %d", align, name, lay.Align)
+			return Layout{}, false
+		}
+		lay.Align = align
+	}
+
+	if lay.Size, ok = calc.alignUp(lay.Size, lay.Align); !ok {
+		calc.tooLarge(ident.Token, name)
+		return Layout{}, false
+	}
+
+	return lay, true
+}
+
+// An enum is laid out as its backing integer, u32 when not given
+func (calc *Calculator) layoutEnum(decl *ast.EnumDecl) (Layout, bool) {
+	if len(decl.Attributes) != 0 {
+		calc.errorf(diag.InvalidAttribute, diag.TokenSpan(decl.Attributes[0].Token), "enum %s cannot have layout attributes, choose a backing type instead", decl.Name.Value)
+		return Layout{}, false
+	}
+
+	size := int64(4)
+
+	if decl.Backing != nil {
+		prim, ok := decl.Backing.(*ast.PrimitiveType)
+		if ok {
+			_, _, ok = token.IntRange(prim.Name)
+		}
+
+		if !ok {
+			calc.errorf(diag.EnumBacking, diag.TokenSpan(decl.Name.Token), "enum %s must be backed by an integer type, got %s", decl.Name.Value, decl.Backing)
+			return Layout{}, false
+		}
+
+		size, _, _ = calc.sizeOf(prim)
+	}
+
+	return Layout{Name: decl.Name.Value, Size: size, Align: size}, true
+}
+
+// Read @packed and @align(N), align is 0 when not given and alignPos is where it was given
+func (calc *Calculator) attributes(attrs []*ast.Attribute) (bool, int64, token.Position, bool) {
+	packed := false
+	align := int64(0)
+	alignPos := token.Position{}
+
+	for _, attr := range attrs {
+		switch attr.Name.Value {
+		case "packed":
+			if len(attr.Args) != 0 {
+				calc.errorf(diag.InvalidAttribute, diag.TokenSpan(attr.Token), "@packed takes no arguments")
+				return false, 0, alignPos, false
+			}
+			packed = true
+		case "align":
+			if len(attr.Args) != 1 {
+				calc.errorf(diag.InvalidAlign, diag.TokenSpan(attr.Token), "@align takes a single power of two")
+				return false, 0, alignPos, false
+			}
+
+			val, ok := calc.constInt(attr.Args[0], attr.Token.Pos)
+			if !ok {
+				return false, 0, alignPos, false
+			}
+
+			if val <= 0 || val&(val-1) != 0 {
+				calc.errorf(diag.InvalidAlign, diag.TokenSpan(attr.Token), "@align(%d) is not a power of two", val)
+				return false, 0, alignPos, false
+			}
+			align = val
+			alignPos = attr.Token.Pos
+		default:
+			calc.errorf(diag.InvalidAttribute, diag.TokenSpan(attr.Token), "unknown attribute @%s", attr.Name.Value)
+			return false, 0, alignPos, false
+		}
+	}
+
+	return packed, align, alignPos, true
+}
+
+// Size and alignment of a type in bytes
+func (calc *Calculator) sizeOf(typ ast.TypeExpr) (int64, int64, bool) {
+	switch node := typ.(type) {
+	case *ast.PrimitiveType:
+		switch node.Name {
+		case "bool", "i8", "u8":
+			return 1, 1, true
+		case "i16", "u16":
+			return 2, 2, true
+		case "i32", "u32", "f32":
+			return 4, 4, true
+		case "i64", "u64", "f64":
+			return 8, 8, true
+		case "i128", "u128":
+			return 16, 16, true
+		}
+	case *ast.VolatileType:
+		return calc.sizeOf(node.Elem)
+	case *ast.PointerType:
+		return calc.ptrSize, calc.ptrSize, true
+	case *ast.ArrayType:
+		n, ok := calc.constInt(node.Len, node.Token.Pos)
+		if !ok {
+			return 0, 0, false
+		}
+
+		if n < 0 {
+			calc.errorf(diag.InvalidLength, diag.TokenSpan(node.Token), "array length %d is negative", n)
+			return 0, 0, false
+		}
+
+		size, align, ok := calc.sizeOf(node.Elem)
+		if !ok {
+			return 0, 0, false
+		}
+
+		if size != 0 && n > calc.maxSize()/size {
+			calc.errorf(diag.TypeTooLarge, diag.NodeSpan(node), "array %s is larger than the %d-bit address space", node, calc.ptrSize*8)
+			return 0, 0, false
+		}
+
+		return n * size, align, true
+	case *ast.NamedType:
+		lay, ok := calc.layoutOf(node.Name, node.Token.Pos)
+		return lay.Size, lay.Align, ok
+	}
+
+	calc.errorf(diag.UnsizedType, diag.At(token.Position{}), "type %s has no known size", typ)
+	return 0, 0, false
+}
+
+// Fold an expression that must be a constant integer
+func (calc *Calculator) constInt(expr ast.Expression, pos token.Position) (int64, bool) {
+	val, err := calc.consts.Eval(expr)
+	if err != nil {
+		calc.diags = append(calc.diags, diag.FromError(err))
+		return 0, false
+	}
+
+	if val.Kind != consteval.Int {
+		calc.errorf(diag.InvalidLength, diag.At(pos), "%s is not a constant integer", expr)
+		return 0, false
+	}
+
+	if !val.Int.IsInt64() {
+		calc.errorf(diag.InvalidLength, diag.At(pos), "%s is too large, it must fit in 64 bits", val.Int)
+		return 0, false
+	}
+
+	return val.Int.Int64(), true
+}
+
+// Largest size of a type, every byte of it must be addressable by a pointer of the target
+func (calc *Calculator) maxSize() int64 {
+	if calc.ptrSize >= 8 {
+		return math.MaxInt64
+	}
+	return 1<<(8*calc.ptrSize) - 1
+}
+
+// Sum of two sizes, ok is false when it does not fit the address space
+func (calc *Calculator) add(a int64, b int64) (int64, bool) {
+	if a > calc.maxSize()-b {
+		return 0, false
+	}
+	return a + b, true
+}
+
+// Round an offset up to the next multiple of align, ok is false when it does not fit the address space
+func (calc *Calculator) alignUp(offset int64, align int64) (int64, bool) {
+	end, ok := calc.add(offset, align-1)
+	return end / align * align, ok
+}
+
+func (calc *Calculator) tooLarge(tok token.Token, name string) {
+	calc.errorf(diag.TypeTooLarge, diag.TokenSpan(tok), "%s is larger than the %d-bit address space", name, calc.ptrSize*8)
+}
+
+// Add an error over a span
+func (calc *Calculator) errorf(code diag.Code, span diag.Span, format string, args ...interface{}) {
+	calc.diags = append(calc.diags, diag.Errorf(code, span, format, args...))
+}
+
+// Name of a struct, union or enum declaration, "" for any other statement
+func declName(stmt ast.Statement) string {
+	switch node := stmt.(type) {
+	case *ast.StructDecl:
+		return node.Name.Value
+	case *ast.UnionDecl:
+		return node.Name.Value
+	case *ast.EnumDecl:
+		return node.Name.Value
+	}
+	return ""
+}
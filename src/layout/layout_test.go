@@ -0,0 +1,94 @@
+package layout
+
+import (
+	"testing"
+
+	"github.com/Urvirith/bearlang/src/lexer"
+	"github.com/Urvirith/bearlang/src/parser"
+)
+
+func calculate(t *testing.T, input string) ([]Layout, []string) {
+	psr := parser.New(lexer.New(input))
+	prg := psr.ParseProgram()
+
+	if len(psr.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", psr.Errors())
+	}
+
+	calc := New(prg, 4)
+	return calc.Calculate(), calc.Errors()
+}
+
+func TestLayouts(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct S { a: u8, b: u32, c: u16 }", "S size=12 align=4\n  a offset=0 size=1 align=1\n  b offset=4 size=4 align=4\n  c offset=8 size=2 align=2"},
+		{"@packed struct S { a: u8, b: u32, c: u16 }", "S size=7 align=1\n  a offset=0 size=1 align=1\n  b offset=1 size=4 align=1\n  c offset=5 size=2 align=1"},
+		{"@align(16) struct S { a: u8 }", "S size=16 align=16\n  a offset=0 size=1 align=1"},
+		{"union U { a: u8, b: u64, c: [3]u16 }", "U size=8 align=8\n  a offset=0 size=1 align=1\n  b offset=0 size=8 align=8\n  c offset=0 size=6 align=2"},
+		{"struct S { next: S*, regs: [N * 2]vol u32 } const N: u32 = 2;", "S size=20 align=4\n  next offset=0 size=4 align=4\n  regs offset=4 size=16 align=4"},
+		{"enum Mode { Idle, Run }", "Mode size=4 align=4"},
+		{"enum Mode: u8 { Idle, Run }", "Mode size=1 align=1"},
+		{"struct S { a: u8 } struct S { b: u16 }", "S size=1 align=1\n  a offset=0 size=1 align=1"},
+		{"struct S { m: Mode, b: bool } enum Mode: u16 { Idle }", "S size=4 align=2\n  m offset=0 size=2 align=2\n  b offset=2 size=1 align=1"},
+	}
+
+	for i, tt := range tests {
+		layouts, errors := calculate(t, tt.input)
+
+		if len(errors) != 0 {
+			t.Fatalf("tests[%d] - unexpected errors %q", i, errors)
+		}
+
+		if layouts[0].String() != tt.expected {
+			t.Errorf("tests[%d] - expected=%q. got=%q", i, tt.expected, layouts[0].String())
+		}
+	}
+}
+
+func TestLayoutErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct S { a: u8, s: S }", "1:22: type S contains itself, use a pointer"},
+		{"struct A { b: B } struct B { a: A }", "1:33: type A contains itself, use a pointer"},
+		{"struct S { a: Missing }", "1:15: unknown type Missing"},
+		{"@align(3) struct S { a: u8 }", "1:1: @align(3) is not a power of two"},
+		{"@align(2) struct S { a: u32 }", "1:1: @align(2) on S is below its natural alignment 4"},
+		{"@packed(1) struct S { a: u8 }", "1:1: @packed takes no arguments"},
+		{"@inline struct S { a: u8 }", "1:1: unknown attribute @inline"},
+		{"@packed enum E { A }", "1:1: enum E cannot have layout attributes, choose a backing type instead"},
+		{"enum E: f32 { A }", "1:6: enum E must be backed by an integer type, got f32"},
+		{"struct S { a: [N]u8 }", "1:16: N is not a constant"},
+		{"struct S { a: [0xFFFFFFFFFFFFFFFF]u8 }", "1:15: 18446744073709551615 is too large, it must fit in 64 bits"},
+		{"struct S { a: [0xFFFFFFFF]u64 }", "1:15: array [0xFFFFFFFF]u64 is larger than the 32-bit address space"},
+		{"struct S { a: [0x80000000]u8, b: [0x80000000]u8 }", "1:31: S is larger than the 32-bit address space"},
+		{"struct S { a: [0xFFFFFFFF]u8, b: u16 }", "1:31: S is larger than the 32-bit address space"},
+	}
+
+	for i, tt := range tests {
+		_, errors := calculate(t, tt.input)
+
+		if len(errors) != 1 || errors[0] != tt.expected {
+			t.Errorf("tests[%d] - expected error %q. got=%q", i, tt.expected, errors)
+		}
+	}
+}
+
+func TestLayoutTooLarge(t *testing.T) {
+	psr := parser.New(lexer.New("struct S { a: [0xFFFFFFFF]u64 } struct T { s: [0xFFFFFFFF]S }"))
+	calc := New(psr.ParseProgram(), 8)
+
+	layouts := calc.Calculate()
+	if len(layouts) != 1 || layouts[0].Size != 0xFFFFFFFF*8 {
+		t.Errorf("wrong layouts %v", layouts)
+	}
+
+	expected := "1:47: array [0xFFFFFFFF]S is larger than the 64-bit address space"
+	if errors := calc.Errors(); len(errors) != 1 || errors[0] != expected {
+		t.Errorf("expected error %q. got=%q", expected, errors)
+	}
+}
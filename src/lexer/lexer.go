@@ -1,27 +1,59 @@
 package lexer
 
-import "github.com/Urvirith/bearlang/src/token"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Urvirith/bearlang/src/diag"
+	"github.com/Urvirith/bearlang/src/token"
+)
 
 // Structure defining the Lexer
 type Lexer struct {
-	in      string // Input data
-	pos     int    // Current position in data - Current Character
-	readPos int    // Current reading position in data - After Current Character
-	ch      byte   // Current Char
+	filename string // Name of the source file, used for positions
+	in       string // Input data
+	pos      int    // Current position in data - Current Character
+	readPos  int    // Current reading position in data - After Current Character
+	ch       byte   // Current Char
+	line     int    // Line of the current character
+	col      int    // Column of the current character
+	comments bool   // Emit comments as tokens instead of skipping them
+	diags    diag.List
 }
 
 // Create new instance and initialize the read position
 func New(in string) *Lexer {
-	lex := &Lexer{in: in}
+	return NewFile("", in)
+}
+
+// Create new instance for a named source file, the name is reported in token positions
+func NewFile(filename string, in string) *Lexer {
+	lex := &Lexer{filename: filename, in: in, line: 1}
 	lex.readChar()
 	return lex
 }
 
+// Emit comments as COMMENT tokens rather than skipping them
+func (lex *Lexer) EmitComments(emit bool) {
+	lex.comments = emit
+}
+
+// Return errors found while reading the input
+func (lex *Lexer) Errors() []string {
+	return lex.diags.Strings()
+}
+
+// Return the diagnostics found while reading the input
+func (lex *Lexer) Diagnostics() diag.List {
+	return lex.diags
+}
+
 // Fetch the next token
 func (lex *Lexer) NextToken() token.Token {
 	var tok token.Token
 
 	lex.consumeWhitespace()
+	start := lex.position()
 
 	switch lex.ch {
 	case '=':
@@ -59,15 +91,44 @@ func (lex *Lexer) NextToken() token.Token {
 			ch := lex.ch
 			lex.readChar()
 			tok = newCompoundToken(token.DEC, string(ch)+string(lex.ch))
+		} else if lex.peekChar() == '>' {
+			ch := lex.ch
+			lex.readChar()
+			tok = newCompoundToken(token.ARROW, string(ch)+string(lex.ch))
 		} else {
 			tok = newToken(token.SUB, lex.ch)
 		}
 	case '*':
-		tok = newToken(token.ASTERISK, lex.ch)
+		if lex.peekChar() == '=' {
+			ch := lex.ch
+			lex.readChar()
+			tok = newCompoundToken(token.MUL_ASSIGN, string(ch)+string(lex.ch))
+		} else {
+			tok = newToken(token.ASTERISK, lex.ch)
+		}
 	case '/':
-		tok = newToken(token.DIV, lex.ch)
+		if lex.peekChar() == '/' || lex.peekChar() == '*' {
+			tok = lex.readComment(start)
+			if tok.Type == token.COMMENT && !lex.comments {
+				return lex.NextToken()
+			}
+			return tok
+		}
+		if lex.peekChar() == '=' {
+			ch := lex.ch
+			lex.readChar()
+			tok = newCompoundToken(token.DIV_ASSIGN, string(ch)+string(lex.ch))
+		} else {
+			tok = newToken(token.DIV, lex.ch)
+		}
 	case '%':
-		tok = newToken(token.MOD, lex.ch)
+		if lex.peekChar() == '=' {
+			ch := lex.ch
+			lex.readChar()
+			tok = newCompoundToken(token.MOD_ASSIGN, string(ch)+string(lex.ch))
+		} else {
+			tok = newToken(token.MOD, lex.ch)
+		}
 	case '|':
 		if lex.peekChar() == '=' {
 			ch := lex.ch
@@ -110,6 +171,11 @@ func (lex *Lexer) NextToken() token.Token {
 			ch := lex.ch
 			lex.readChar()
 			tok = newCompoundToken(token.LSHF, string(ch)+string(lex.ch))
+
+			if lex.peekChar() == '=' {
+				lex.readChar()
+				tok = newCompoundToken(token.LSHF_ASSIGN, tok.Literal+string(lex.ch))
+			}
 		} else {
 			tok = newToken(token.LES, lex.ch)
 		}
@@ -122,6 +188,11 @@ func (lex *Lexer) NextToken() token.Token {
 			ch := lex.ch
 			lex.readChar()
 			tok = newCompoundToken(token.RSHF, string(ch)+string(lex.ch))
+
+			if lex.peekChar() == '=' {
+				lex.readChar()
+				tok = newCompoundToken(token.RSHF_ASSIGN, tok.Literal+string(lex.ch))
+			}
 		} else {
 			tok = newToken(token.GRT, lex.ch)
 		}
@@ -153,29 +224,74 @@ func (lex *Lexer) NextToken() token.Token {
 		tok = newToken(token.COLON, lex.ch)
 	case ';':
 		tok = newToken(token.SCOLON, lex.ch)
+	case '@':
+		tok = newToken(token.AT, lex.ch)
+	case '.':
+		if lex.peekChar() == '.' {
+			ch := lex.ch
+			lex.readChar()
+			tok = newCompoundToken(token.RANGE, string(ch)+string(lex.ch))
+
+			if lex.peekChar() == '=' {
+				lex.readChar()
+				tok = newCompoundToken(token.RANGE_INCL, tok.Literal+string(lex.ch))
+			}
+		} else {
+			tok = newToken(token.DOT, lex.ch)
+		}
+	case '\'', '"':
+		return lex.readQuoted(start)
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
+		return lex.span(tok, start)
 	default:
 		if isLetter(lex.ch) {
 			tok.Literal = lex.readID()
 			tok.Type = token.LookupID(tok.Literal)
-			return tok
+			return lex.span(tok, start)
 		} else if isDigit(lex.ch) {
-			tok.Type = token.INT
-			tok.Literal = lex.readNumber()
-			return tok
+			tok.Type, tok.Literal = lex.readNumber()
+			if msg := validateNumber(tok.Type, tok.Literal); msg != "" {
+				tok.Type = token.ILLEGAL
+				lex.errorf(diag.InvalidNumber, start, "%s", msg)
+			}
+			return lex.span(tok, start)
 		} else {
 			tok = newToken(token.ILLEGAL, lex.ch)
+			lex.errorf(diag.IllegalCharacter, start, "illegal character %q", lex.ch)
 		}
 	}
 
 	lex.readChar()
+	return lex.span(tok, start)
+}
+
+// Position of the current character
+func (lex *Lexer) position() token.Position {
+	return token.Position{
+		Filename: lex.filename,
+		Offset:   lex.pos,
+		Line:     lex.line,
+		Column:   lex.col,
+	}
+}
+
+// Attach the start position and the current position as the end of the token
+func (lex *Lexer) span(tok token.Token, start token.Position) token.Token {
+	tok.Pos = start
+	tok.End = lex.position()
 	return tok
 }
 
 // Read the character of the input string
 func (lex *Lexer) readChar() {
+	// Leaving a newline moves the position to the start of the next line
+	if lex.ch == '\n' {
+		lex.line += 1
+		lex.col = 0
+	}
+
 	// Read the Character or prevent overflow of read from the readPos
 	if lex.readPos < len(lex.in) {
 		lex.ch = lex.in[lex.readPos]
@@ -184,6 +300,7 @@ func (lex *Lexer) readChar() {
 	}
 	lex.pos = lex.readPos
 	lex.readPos += 1
+	lex.col += 1
 }
 
 // Read the identifier of the input string
@@ -196,14 +313,164 @@ func (lex *Lexer) readID() string {
 	return lex.in[pos:lex.pos]
 }
 
-// Read the digits of the input string
-func (lex *Lexer) readNumber() string {
+// Read the digits of the input string, returning INT or FLOAT with the literal
+func (lex *Lexer) readNumber() (token.TokenType, string) {
 	// Read the Identifer or prevent overflow of read from the readPos
 	pos := lex.pos
-	for isDigit(lex.ch) {
+
+	// Based literals take their prefix, any trailing letters are kept so they can be reported
+	if lex.ch == '0' && isBasePrefix(lex.peekChar()) {
+		lex.readChar()
 		lex.readChar()
+		for isLetter(lex.ch) || isDigit(lex.ch) {
+			lex.readChar()
+		}
+		return token.INT, lex.in[pos:lex.pos]
+	}
+
+	tokType := token.TokenType(token.INT)
+
+	for {
+		switch {
+		case isDigit(lex.ch) || isLetter(lex.ch):
+			if lex.ch == 'e' || lex.ch == 'E' {
+				tokType = token.FLOAT
+
+				// The sign of an exponent belongs to the number
+				if lex.peekChar() == '+' || lex.peekChar() == '-' {
+					lex.readChar()
+				}
+			}
+			lex.readChar()
+		case lex.ch == '.' && isDigit(lex.peekChar()):
+			// A dot only belongs to the number when a digit follows, so 0..10 is a range
+			tokType = token.FLOAT
+			lex.readChar()
+		default:
+			// A float suffix makes a float of a whole number, 1f32
+			if _, sfx := token.SplitSuffix(lex.in[pos:lex.pos]); sfx == "f32" || sfx == "f64" {
+				tokType = token.FLOAT
+			}
+			return tokType, lex.in[pos:lex.pos]
+		}
 	}
-	return lex.in[pos:lex.pos]
+}
+
+// Read a char or string literal including its quotes, the escapes are checked but kept as written
+func (lex *Lexer) readQuoted(start token.Position) token.Token {
+	quote := lex.ch
+	pos := lex.pos
+	lex.readChar()
+
+	for lex.ch != quote {
+		if lex.ch == 0 || lex.ch == '\n' {
+			lex.errorf(diag.UnterminatedLiteral, start, "unterminated literal, missing closing %c", quote)
+			return lex.span(newCompoundToken(token.ILLEGAL, lex.in[pos:lex.pos]), start)
+		}
+
+		// Skip the escaped character so an escaped quote does not end the literal
+		if lex.ch == '\\' && lex.peekChar() != 0 && lex.peekChar() != '\n' {
+			lex.readChar()
+		}
+		lex.readChar()
+	}
+	lex.readChar()
+
+	lit := lex.in[pos:lex.pos]
+	value, err := Unescape(lit[1 : len(lit)-1])
+
+	switch {
+	case err != nil:
+		lex.errorf(diag.InvalidEscape, start, "%s", err)
+		return lex.span(newCompoundToken(token.ILLEGAL, lit), start)
+	case quote == '"':
+		return lex.span(newCompoundToken(token.STRING, lit), start)
+	case len(value) != 1:
+		lex.errorf(diag.CharLength, start, "char literal %s must be a single byte, got %d", lit, len(value))
+		return lex.span(newCompoundToken(token.ILLEGAL, lit), start)
+	}
+
+	return lex.span(newCompoundToken(token.CHAR, lit), start)
+}
+
+// Decode the escapes of a char or string literal body, \n \r \t \0 \\ \' \" and \xHH
+func Unescape(body string) ([]byte, error) {
+	out := make([]byte, 0, len(body))
+
+	for i := 0; i < len(body); i++ {
+		if body[i] != '\\' {
+			out = append(out, body[i])
+			continue
+		}
+
+		i++
+		if i >= len(body) {
+			return nil, fmt.Errorf("unfinished escape sequence")
+		}
+
+		switch body[i] {
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case '0':
+			out = append(out, 0)
+		case '\\', '\'', '"':
+			out = append(out, body[i])
+		case 'x':
+			if i+2 >= len(body) || digitValue(body[i+1]) >= 16 || digitValue(body[i+2]) >= 16 {
+				return nil, fmt.Errorf("escape \\x needs two hex digits")
+			}
+			out = append(out, byte(digitValue(body[i+1])<<4|digitValue(body[i+2])))
+			i += 2
+		default:
+			return nil, fmt.Errorf("unknown escape sequence \\%c", body[i])
+		}
+	}
+
+	return out, nil
+}
+
+// Read a line comment or a nestable block comment, starting at the leading /
+func (lex *Lexer) readComment(start token.Position) token.Token {
+	pos := lex.pos
+
+	if lex.peekChar() == '/' {
+		for lex.ch != '\n' && lex.ch != 0 {
+			lex.readChar()
+		}
+		return lex.span(newCompoundToken(token.COMMENT, lex.in[pos:lex.pos]), start)
+	}
+
+	// Skip the opening /*, then track nesting until the matching */
+	lex.readChar()
+	lex.readChar()
+	depth := 1
+
+	for depth > 0 {
+		switch {
+		case lex.ch == 0:
+			lex.errorf(diag.UnterminatedComment, start, "unterminated block comment")
+			return lex.span(newCompoundToken(token.ILLEGAL, lex.in[pos:lex.pos]), start)
+		case lex.ch == '/' && lex.peekChar() == '*':
+			lex.readChar()
+			depth += 1
+		case lex.ch == '*' && lex.peekChar() == '/':
+			lex.readChar()
+			depth -= 1
+		}
+		lex.readChar()
+	}
+
+	return lex.span(newCompoundToken(token.COMMENT, lex.in[pos:lex.pos]), start)
+}
+
+// Add an error spanning from start to the current character
+func (lex *Lexer) errorf(code diag.Code, start token.Position, format string, args ...interface{}) {
+	span := diag.Span{Start: start, End: lex.position()}
+	lex.diags = append(lex.diags, diag.Errorf(code, span, format, args...))
 }
 
 // Consume whitespace as it serves no purpose
@@ -228,9 +495,110 @@ func isLetter(ch byte) bool {
 	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
 }
 
+// Verify is the second character of a 0x, 0b or 0o prefix
+func isBasePrefix(ch byte) bool {
+	return ch == 'x' || ch == 'X' || ch == 'b' || ch == 'B' || ch == 'o' || ch == 'O'
+}
+
+// Verify a number literal is well formed, returns an error message or ""
+func validateNumber(tokType token.TokenType, lit string) string {
+	body, sfx := token.SplitSuffix(lit)
+
+	if tokType == token.FLOAT {
+		if sfx != "" && sfx[0] != 'f' {
+			return fmt.Sprintf("float %s cannot have integer suffix %s", lit, sfx)
+		}
+		return validateFloat(body)
+	}
+	return validateInt(body)
+}
+
+// Verify a float literal has the form digits[.digits][e[+-]digits], returns an error message or ""
+func validateFloat(lit string) string {
+	mantissa, exponent := lit, ""
+
+	if i := strings.IndexAny(lit, "eE"); i >= 0 {
+		mantissa, exponent = lit[:i], strings.TrimLeft(lit[i+1:], "+-")
+		if !isDecimalDigits(exponent) {
+			return fmt.Sprintf("malformed exponent in %s", lit)
+		}
+	}
+
+	parts := strings.Split(mantissa, ".")
+	if len(parts) > 2 {
+		return fmt.Sprintf("%s has more than one decimal point", lit)
+	}
+
+	for _, part := range parts {
+		if !isDecimalDigits(part) {
+			return fmt.Sprintf("malformed float %s", lit)
+		}
+	}
+
+	return ""
+}
+
+// Verify a run of decimal digits, _ may only appear between digits
+func isDecimalDigits(digits string) bool {
+	if digits == "" || digits[0] == '_' || digits[len(digits)-1] == '_' || strings.Contains(digits, "__") {
+		return false
+	}
+
+	for i := 0; i < len(digits); i++ {
+		if !isDigit(digits[i]) && digits[i] != '_' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Verify every digit of an integer literal is valid for its base, returns an error message or ""
+func validateInt(lit string) string {
+	base, digits := token.SplitInt(lit)
+
+	if digits == "" || digits == "_" {
+		return fmt.Sprintf("%s has no digits", lit)
+	}
+
+	if digits[len(digits)-1] == '_' {
+		return fmt.Sprintf("%s must not end with _", lit)
+	}
+
+	for i := 0; i < len(digits); i++ {
+		ch := digits[i]
+
+		if ch == '_' {
+			if i+1 < len(digits) && digits[i+1] == '_' {
+				return fmt.Sprintf("%s must not contain consecutive _", lit)
+			}
+			continue
+		}
+
+		if digitValue(ch) >= base {
+			return fmt.Sprintf("invalid digit %q in %s", ch, lit)
+		}
+	}
+
+	return ""
+}
+
+// Value of a digit in any base up to 16, anything else is out of range
+func digitValue(ch byte) int {
+	switch {
+	case '0' <= ch && ch <= '9':
+		return int(ch - '0')
+	case 'a' <= ch && ch <= 'f':
+		return int(ch-'a') + 10
+	case 'A' <= ch && ch <= 'F':
+		return int(ch-'A') + 10
+	}
+	return 16
+}
+
 // Verify is number
 func isDigit(ch byte) bool {
-	return '0' <= ch && ch <= '9' || ch == '.'
+	return '0' <= ch && ch <= '9'
 }
 
 // Read the character of the input string without moving forward
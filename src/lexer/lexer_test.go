@@ -7,7 +7,7 @@ import (
 )
 
 func TestTokens(t *testing.T) {
-	input := `= + - * / % | & ! ~ ^ += -= ++ -- |= &= ^= << >> == != > < >= <= || && => ( ) { } [ ] , : ; import fn let vol struct enum union const return if elif else match default for loop while true false i8 i16 i32 i64 i128 u8 u16 u32 u64 u128 f32 f64 bool`
+	input := `= + - * / % | & ! ~ ^ += -= ++ -- |= &= ^= << >> == != > < >= <= || && => ( ) { } [ ] , : ; import fn let vol struct enum union const return if elif else match default for loop while true false i8 i16 i32 i64 i128 u8 u16 u32 u64 u128 f32 f64 bool -> ext in .. ..= break continue @ *= /= %= <<= >>= . gpio.moder`
 
 	tests := []struct {
 		expectType    token.TokenType
@@ -83,6 +83,23 @@ func TestTokens(t *testing.T) {
 		{token.F32, "f32"},
 		{token.F64, "f64"},
 		{token.BOOL, "bool"},
+		{token.ARROW, "->"},
+		{token.EXTERN, "ext"},
+		{token.IN, "in"},
+		{token.RANGE, ".."},
+		{token.RANGE_INCL, "..="},
+		{token.BREAK, "break"},
+		{token.CONTINUE, "continue"},
+		{token.AT, "@"},
+		{token.MUL_ASSIGN, "*="},
+		{token.DIV_ASSIGN, "/="},
+		{token.MOD_ASSIGN, "%="},
+		{token.LSHF_ASSIGN, "<<="},
+		{token.RSHF_ASSIGN, ">>="},
+		{token.DOT, "."},
+		{token.IDENTIFIER, "gpio"},
+		{token.DOT, "."},
+		{token.IDENTIFIER, "moder"},
 		{token.EOF, ""},
 	}
 
@@ -180,7 +197,7 @@ func TestNextToken(t *testing.T) {
 			  };
 			  
 			  let result = add(five, ten);
-			  !-/*5;
+			  !-/ *5;
 
 			  if 5 <= 10 {
 				return true;
@@ -290,7 +307,7 @@ func TestNextToken(t *testing.T) {
 		{token.COLON, ":"},
 		{token.F32, "f32"},
 		{token.ASSIGN, "="},
-		{token.INT, "20.0"},
+		{token.FLOAT, "20.0"},
 		{token.SCOLON, ";"},
 		{token.EOF, ""},
 	}
@@ -309,3 +326,263 @@ func TestNextToken(t *testing.T) {
 		}
 	}
 }
+
+func TestPositions(t *testing.T) {
+	input := "let x: u32 = 5;\n\tx >= 10;"
+
+	tests := []struct {
+		expectLiteral string
+		expectPos     token.Position
+		expectEnd     token.Position
+	}{
+		{"let", token.Position{Filename: "main.bl", Offset: 0, Line: 1, Column: 1}, token.Position{Filename: "main.bl", Offset: 3, Line: 1, Column: 4}},
+		{"x", token.Position{Filename: "main.bl", Offset: 4, Line: 1, Column: 5}, token.Position{Filename: "main.bl", Offset: 5, Line: 1, Column: 6}},
+		{":", token.Position{Filename: "main.bl", Offset: 5, Line: 1, Column: 6}, token.Position{Filename: "main.bl", Offset: 6, Line: 1, Column: 7}},
+		{"u32", token.Position{Filename: "main.bl", Offset: 7, Line: 1, Column: 8}, token.Position{Filename: "main.bl", Offset: 10, Line: 1, Column: 11}},
+		{"=", token.Position{Filename: "main.bl", Offset: 11, Line: 1, Column: 12}, token.Position{Filename: "main.bl", Offset: 12, Line: 1, Column: 13}},
+		{"5", token.Position{Filename: "main.bl", Offset: 13, Line: 1, Column: 14}, token.Position{Filename: "main.bl", Offset: 14, Line: 1, Column: 15}},
+		{";", token.Position{Filename: "main.bl", Offset: 14, Line: 1, Column: 15}, token.Position{Filename: "main.bl", Offset: 15, Line: 1, Column: 16}},
+		{"x", token.Position{Filename: "main.bl", Offset: 17, Line: 2, Column: 2}, token.Position{Filename: "main.bl", Offset: 18, Line: 2, Column: 3}},
+		{">=", token.Position{Filename: "main.bl", Offset: 19, Line: 2, Column: 4}, token.Position{Filename: "main.bl", Offset: 21, Line: 2, Column: 6}},
+		{"10", token.Position{Filename: "main.bl", Offset: 22, Line: 2, Column: 7}, token.Position{Filename: "main.bl", Offset: 24, Line: 2, Column: 9}},
+		{";", token.Position{Filename: "main.bl", Offset: 24, Line: 2, Column: 9}, token.Position{Filename: "main.bl", Offset: 25, Line: 2, Column: 10}},
+		{"", token.Position{Filename: "main.bl", Offset: 25, Line: 2, Column: 10}, token.Position{Filename: "main.bl", Offset: 25, Line: 2, Column: 10}},
+	}
+
+	l := NewFile("main.bl", input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Literal != tt.expectLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected: %q, got: %q", i, tt.expectLiteral, tok.Literal)
+		}
+
+		if tok.Pos != tt.expectPos {
+			t.Fatalf("tests[%d] - pos wrong. expected: %+v, got: %+v", i, tt.expectPos, tok.Pos)
+		}
+
+		if tok.End != tt.expectEnd {
+			t.Fatalf("tests[%d] - end wrong. expected: %+v, got: %+v", i, tt.expectEnd, tok.End)
+		}
+	}
+
+	if tests[7].expectPos.String() != "main.bl:2:2" {
+		t.Fatalf("position string wrong. expected: %q, got: %q", "main.bl:2:2", tests[7].expectPos.String())
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `let x: u32 = 5; // trailing comment
+			  /* block /* nested */ still comment */ x;`
+
+	tests := []struct {
+		expectType    token.TokenType
+		expectLiteral string
+	}{
+		{token.LET, "let"},
+		{token.IDENTIFIER, "x"},
+		{token.COLON, ":"},
+		{token.U32, "u32"},
+		{token.ASSIGN, "="},
+		{token.INT, "5"},
+		{token.SCOLON, ";"},
+		{token.COMMENT, "// trailing comment"},
+		{token.COMMENT, "/* block /* nested */ still comment */"},
+		{token.IDENTIFIER, "x"},
+		{token.SCOLON, ";"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	l.EmitComments(true)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected: %q, got: %q", i, tt.expectType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected: %q, got: %q", i, tt.expectLiteral, tok.Literal)
+		}
+	}
+
+	// Comments are skipped by default
+	l = New(input)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		if tok.Type == token.COMMENT {
+			t.Fatalf("comment emitted while skipping comments: %q", tok.Literal)
+		}
+	}
+
+	if len(l.Errors()) != 0 {
+		t.Fatalf("unexpected lexer errors: %v", l.Errors())
+	}
+}
+
+func TestUnterminatedComment(t *testing.T) {
+	l := New("x; /* open /* nested */ never closed")
+
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+	}
+
+	if len(l.Errors()) != 1 {
+		t.Fatalf("expected 1 lexer error, got: %v", l.Errors())
+	}
+
+	if l.Errors()[0] != "1:4: unterminated block comment" {
+		t.Fatalf("error wrong. got: %q", l.Errors()[0])
+	}
+}
+
+func TestIntegerBases(t *testing.T) {
+	input := `0x42020000 0X4c 0b1010_0000 0o777 1_200_000 0777 0b102 0x 7_ 1__0 12ab`
+
+	tests := []struct {
+		expectType    token.TokenType
+		expectLiteral string
+	}{
+		{token.INT, "0x42020000"},
+		{token.INT, "0X4c"},
+		{token.INT, "0b1010_0000"},
+		{token.INT, "0o777"},
+		{token.INT, "1_200_000"},
+		{token.INT, "0777"},
+		{token.ILLEGAL, "0b102"},
+		{token.ILLEGAL, "0x"},
+		{token.ILLEGAL, "7_"},
+		{token.ILLEGAL, "1__0"},
+		{token.ILLEGAL, "12ab"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected: %q, got: %q", i, tt.expectType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected: %q, got: %q", i, tt.expectLiteral, tok.Literal)
+		}
+	}
+
+	if len(l.Errors()) != 5 {
+		t.Fatalf("expected 5 lexer errors, got: %v", l.Errors())
+	}
+}
+
+func TestFloats(t *testing.T) {
+	input := `20.0 1.5e-3 2E+10 3e8 1_000.25 1.2.3 1.e5 0..10`
+
+	tests := []struct {
+		expectType    token.TokenType
+		expectLiteral string
+	}{
+		{token.FLOAT, "20.0"},
+		{token.FLOAT, "1.5e-3"},
+		{token.FLOAT, "2E+10"},
+		{token.FLOAT, "3e8"},
+		{token.FLOAT, "1_000.25"},
+		{token.ILLEGAL, "1.2.3"},
+		{token.INT, "1"},
+		{token.DOT, "."},
+		{token.IDENTIFIER, "e5"},
+		{token.INT, "0"},
+		{token.RANGE, ".."},
+		{token.INT, "10"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected: %q, got: %q", i, tt.expectType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected: %q, got: %q", i, tt.expectLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNumberSuffixes(t *testing.T) {
+	input := `255u8 0xFFFF_FFFFu64 1i128 1.5f32 1f64 0xFf32 1.5u8`
+
+	tests := []struct {
+		expectType    token.TokenType
+		expectLiteral string
+	}{
+		{token.INT, "255u8"},
+		{token.INT, "0xFFFF_FFFFu64"},
+		{token.INT, "1i128"},
+		{token.FLOAT, "1.5f32"},
+		{token.FLOAT, "1f64"},
+		{token.INT, "0xFf32"},
+		{token.ILLEGAL, "1.5u8"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected: %q, got: %q", i, tt.expectType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected: %q, got: %q", i, tt.expectLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestCharAndString(t *testing.T) {
+	input := `'A' '\n' '\x7F' '\0' '\'' "hello\n" "say \"hi\"" "" 'AB' '\q' "\x7" "open`
+
+	tests := []struct {
+		expectType    token.TokenType
+		expectLiteral string
+	}{
+		{token.CHAR, `'A'`},
+		{token.CHAR, `'\n'`},
+		{token.CHAR, `'\x7F'`},
+		{token.CHAR, `'\0'`},
+		{token.CHAR, `'\''`},
+		{token.STRING, `"hello\n"`},
+		{token.STRING, `"say \"hi\""`},
+		{token.STRING, `""`},
+		{token.ILLEGAL, `'AB'`},
+		{token.ILLEGAL, `'\q'`},
+		{token.ILLEGAL, `"\x7"`},
+		{token.ILLEGAL, `"open`},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected: %q, got: %q", i, tt.expectType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected: %q, got: %q", i, tt.expectLiteral, tok.Literal)
+		}
+	}
+
+	if len(l.Errors()) != 4 {
+		t.Fatalf("expected 4 lexer errors, got: %v", l.Errors())
+	}
+}
@@ -7,8 +7,12 @@ import (
 	"github.com/Urvirith/bearlang/src/repl"
 )
 
-// Test REPL Keyring
+// Test REPL Keyring, bearlang fmt formats source files instead
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "fmt" {
+		os.Exit(formatCommand(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	}
+
 	fmt.Printf("This is the REPL of BearLang\n")
 	fmt.Printf("Type in a command\n")
 	repl.Start(os.Stdin, os.Stdout)
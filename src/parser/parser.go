@@ -1,10 +1,13 @@
 package parser
 
 import (
-	"fmt"
+	"math"
+	"math/big"
 	"strconv"
+	"strings"
 
 	"github.com/Urvirith/bearlang/src/ast"
+	"github.com/Urvirith/bearlang/src/diag"
 	"github.com/Urvirith/bearlang/src/lexer"
 	"github.com/Urvirith/bearlang/src/token"
 )
@@ -15,7 +18,11 @@ type Parser struct {
 	peekToken      token.Token
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
-	errors         []string
+	diags          diag.List
+	lexErrors      int            // Number of lexer diagnostics already carried over
+	comments       []*ast.Comment // Comments collected from the lexer
+	loops          []string       // Labels of the enclosing loops, innermost last, "" when unlabelled
+	braces         int            // Number of { less the number of } read, up to and including curToken
 }
 
 type prefixParseFn func() ast.Expression
@@ -37,32 +44,94 @@ var datatypes = []token.TokenType{
 	token.BOOL,
 }
 
+// Binding power of each infix operator, every level is left associative.
+// The bitwise operators bind tighter than the comparisons, so a & MASK == 0
+// is (a & MASK) == 0 rather than C's a & (MASK == 0).
 var precedences = map[token.TokenType]int{
-	token.EQU:      EQUALS,
-	token.NEQ:      EQUALS,
-	token.LES:      LESSGREATER,
-	token.GRT:      LESSGREATER,
-	token.ADD:      SUM,
-	token.SUB:      SUM,
-	token.DIV:      PRODUCT,
-	token.ASTERISK: PRODUCT,
+	token.RANGE:      RANGE,
+	token.RANGE_INCL: RANGE,
+	token.COR:        LOGICOR,
+	token.CAND:       LOGICAND,
+	token.EQU:        EQUALS,
+	token.NEQ:        EQUALS,
+	token.LES:        LESSGREATER,
+	token.GRT:        LESSGREATER,
+	token.LEQ:        LESSGREATER,
+	token.GEQ:        LESSGREATER,
+	token.OR:         BITOR,
+	token.XOR:        BITXOR,
+	token.AND:        BITAND,
+	token.LSHF:       SHIFT,
+	token.RSHF:       SHIFT,
+	token.ADD:        SUM,
+	token.SUB:        SUM,
+	token.DIV:        PRODUCT,
+	token.ASTERISK:   PRODUCT,
+	token.MOD:        PRODUCT,
+	token.LPAREN:     CALL,
+	token.LBRACK:     CALL,
+	token.DOT:        CALL,
+}
+
+// Tokens that can only start a statement, parsing picks up again at them after an error
+var statementStarts = map[token.TokenType]bool{
+	token.IMPORT:   true,
+	token.LET:      true,
+	token.CONST:    true,
+	token.FUNCTION: true,
+	token.EXTERN:   true,
+	token.STRUCT:   true,
+	token.UNION:    true,
+	token.ENUM:     true,
+	token.AT:       true,
+	token.RETURN:   true,
+	token.IF:       true,
+	token.LOOP:     true,
+	token.WHILE:    true,
+	token.FOR:      true,
+	token.BREAK:    true,
+	token.CONTINUE: true,
+}
+
+// Operators that write to their left hand side
+var assignOps = map[token.TokenType]bool{
+	token.ASSIGN:      true,
+	token.ADD_ASSIGN:  true,
+	token.SUB_ASSIGN:  true,
+	token.MUL_ASSIGN:  true,
+	token.DIV_ASSIGN:  true,
+	token.MOD_ASSIGN:  true,
+	token.OR_ASSIGN:   true,
+	token.AND_ASSIGN:  true,
+	token.XOR_ASSIGN:  true,
+	token.LSHF_ASSIGN: true,
+	token.RSHF_ASSIGN: true,
+	token.INC:         true,
+	token.DEC:         true,
 }
 
 const (
 	_ int = iota
 	LOWEST
-	EQUALS
-	LESSGREATER
-	SUM
-	PRODUCT
-	PREFIX
-	CALL
+	RANGE       // a..b a..=b
+	LOGICOR     // ||
+	LOGICAND    // &&
+	EQUALS      // == !=
+	LESSGREATER // < > <= >=
+	BITOR       // |
+	BITXOR      // ^
+	BITAND      // &
+	SHIFT       // << >>
+	SUM         // + -
+	PRODUCT     // * / %
+	PREFIX      // -x !x ~x *x &x
+	CALL        // f(x) a[i] a.b
 )
 
 func New(lex *lexer.Lexer) *Parser {
 	psr := &Parser{
-		lex:    lex,
-		errors: []string{},
+		lex:   lex,
+		diags: diag.List{},
 	}
 
 	// Read two tokens, curToken and peekToken are set
@@ -75,11 +144,18 @@ func New(lex *lexer.Lexer) *Parser {
 	// REGISTER PREFIXES
 	psr.registerPrefix(token.IDENTIFIER, psr.parseIdentifier)
 	psr.registerPrefix(token.INT, psr.parseIntegerLiteral)
+	psr.registerPrefix(token.FLOAT, psr.parseFloatLiteral)
+	psr.registerPrefix(token.CHAR, psr.parseCharLiteral)
+	psr.registerPrefix(token.STRING, psr.parseStringLiteral)
 	psr.registerPrefix(token.SUB, psr.parsePrefixExpression)
 	psr.registerPrefix(token.NOT, psr.parsePrefixExpression)
+	psr.registerPrefix(token.COMP, psr.parsePrefixExpression)
+	psr.registerPrefix(token.ASTERISK, psr.parseDerefExpression)
+	psr.registerPrefix(token.AND, psr.parseAddressOf)
 	psr.registerPrefix(token.TRUE, psr.parseBoolean)
 	psr.registerPrefix(token.FALSE, psr.parseBoolean)
 	psr.registerPrefix(token.LPAREN, psr.parseGroupExpression)
+	psr.registerPrefix(token.MATCH, psr.parseMatchExpression)
 
 	psr.registerInfix(token.ADD, psr.parseInfixExpression)
 	psr.registerInfix(token.SUB, psr.parseInfixExpression)
@@ -89,6 +165,21 @@ func New(lex *lexer.Lexer) *Parser {
 	psr.registerInfix(token.NEQ, psr.parseInfixExpression)
 	psr.registerInfix(token.GRT, psr.parseInfixExpression)
 	psr.registerInfix(token.LES, psr.parseInfixExpression)
+	psr.registerInfix(token.GEQ, psr.parseInfixExpression)
+	psr.registerInfix(token.LEQ, psr.parseInfixExpression)
+	psr.registerInfix(token.MOD, psr.parseInfixExpression)
+	psr.registerInfix(token.AND, psr.parseInfixExpression)
+	psr.registerInfix(token.OR, psr.parseInfixExpression)
+	psr.registerInfix(token.XOR, psr.parseInfixExpression)
+	psr.registerInfix(token.LSHF, psr.parseInfixExpression)
+	psr.registerInfix(token.RSHF, psr.parseInfixExpression)
+	psr.registerInfix(token.CAND, psr.parseInfixExpression)
+	psr.registerInfix(token.COR, psr.parseInfixExpression)
+	psr.registerInfix(token.LPAREN, psr.parseCallExpression)
+	psr.registerInfix(token.LBRACK, psr.parseIndexExpression)
+	psr.registerInfix(token.DOT, psr.parseFieldExpression)
+	psr.registerInfix(token.RANGE, psr.parseRangeExpression)
+	psr.registerInfix(token.RANGE_INCL, psr.parseRangeExpression)
 
 	return psr
 }
@@ -98,30 +189,69 @@ func (psr *Parser) ParseProgram() *ast.Program {
 	prg.Statements = []ast.Statement{}
 
 	for psr.curToken.Type != token.EOF {
-		stmt := psr.parseStatement()
-		println(stmt.String())
-		if stmt != nil {
+		start := psr.braces
+
+		// A failed statement has reported its errors, skip what is left of it and carry on
+		if stmt := psr.parseStatement(); stmt != nil {
 			prg.Statements = append(prg.Statements, stmt)
+		} else {
+			psr.synchronize(start)
 		}
 		psr.nextToken()
 	}
 
+	prg.Comments = psr.comments
+
 	return prg
 }
 
 func (psr *Parser) parseStatement() ast.Statement {
+	// The parse functions returning pointers are checked here, so a failed
+	// statement is a nil Statement rather than a Statement holding a nil pointer
 	switch psr.curToken.Type {
 	case token.LET:
-		return psr.parseLetStatement()
+		if stmt := psr.parseLetStatement(); stmt != nil {
+			return stmt
+		}
+		return nil
+	case token.CONST:
+		if stmt := psr.parseConstStatement(); stmt != nil {
+			return stmt
+		}
+		return nil
+	case token.FUNCTION, token.EXTERN:
+		if decl := psr.parseFunctionDecl(); decl != nil {
+			return decl
+		}
+		return nil
+	case token.STRUCT, token.UNION, token.ENUM, token.AT:
+		return psr.parseTypeDecl()
 	case token.RETURN:
-		return psr.parseReturnStatement()
+		if stmt := psr.parseReturnStatement(); stmt != nil {
+			return stmt
+		}
+		return nil
+	case token.IF:
+		if stmt := psr.parseIfStatement(); stmt != nil {
+			return stmt
+		}
+		return nil
+	case token.LOOP, token.WHILE, token.FOR:
+		return psr.parseLoop(nil)
+	case token.BREAK, token.CONTINUE:
+		return psr.parseBranchStatement()
+	case token.IDENTIFIER:
+		// An identifier followed by : labels the loop after it
+		if psr.peekTokenIs(token.COLON) {
+			return psr.parseLabelledLoop()
+		}
+		return psr.parseExpressionStatement()
 	default:
 		return psr.parseExpressionStatement()
-		//return nil
 	}
 }
 
-// Parse the let statement
+// Parse the let statement, let name: type = value; where the type may be left out to be inferred
 func (psr *Parser) parseLetStatement() *ast.LetStatement {
 	stmt := &ast.LetStatement{Token: psr.curToken}
 
@@ -132,56 +262,707 @@ func (psr *Parser) parseLetStatement() *ast.LetStatement {
 
 	stmt.Name = &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}
 
-	// Identifer Is Not Followed By :
-	if !psr.expectPeek(token.COLON) {
+	// Identifer Is Followed By : DataType
+	if psr.peekTokenIs(token.COLON) {
+		psr.nextToken()
+		psr.nextToken()
+
+		if stmt.Type = psr.parseType(); stmt.Type == nil {
+			return nil
+		}
+	}
+
+	// Identifer is not followed by an =
+	if !psr.expectPeek(token.ASSIGN) {
 		return nil
 	}
 
-	// Identifer Is Not Followed DataType :
-	if !psr.expectPeekDataType() {
+	psr.nextToken()
+
+	if stmt.Value = psr.parseExpression(LOWEST); stmt.Value == nil {
 		return nil
 	}
 
-	// Identifer is not followed by an =
+	if !psr.expectPeek(token.SCOLON) {
+		return nil
+	}
+
+	psr.checkLiteralType(stmt.Value, stmt.Type)
+
+	return stmt
+}
+
+// Parse the const statement, const NAME: type = value; the value is folded later by consteval
+func (psr *Parser) parseConstStatement() *ast.ConstStatement {
+	stmt := &ast.ConstStatement{Token: psr.curToken}
+
+	if !psr.expectPeek(token.IDENTIFIER) {
+		return nil
+	}
+
+	stmt.Name = &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}
+
+	if psr.peekTokenIs(token.COLON) {
+		psr.nextToken()
+		psr.nextToken()
+
+		if stmt.Type = psr.parseType(); stmt.Type == nil {
+			return nil
+		}
+	}
+
 	if !psr.expectPeek(token.ASSIGN) {
 		return nil
 	}
 
-	// TODO : Skimming expressioins until semicolon
-	for !psr.curTokenIs(token.SCOLON) {
+	psr.nextToken()
+
+	if stmt.Value = psr.parseExpression(LOWEST); stmt.Value == nil {
+		return nil
+	}
+
+	if !psr.expectPeek(token.SCOLON) {
+		return nil
+	}
+
+	psr.checkLiteralType(stmt.Value, stmt.Type)
+
+	return stmt
+}
+
+// Parse a function, [ext] fn name(param: type, ...) [-> type] { ... }
+func (psr *Parser) parseFunctionDecl() *ast.FunctionDecl {
+	decl := &ast.FunctionDecl{}
+
+	if psr.curTokenIs(token.EXTERN) {
+		decl.Linkage = psr.curToken
+
+		if !psr.expectPeek(token.FUNCTION) {
+			return nil
+		}
+	}
+
+	decl.Token = psr.curToken
+
+	if !psr.expectPeek(token.IDENTIFIER) {
+		return nil
+	}
+
+	decl.Name = &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}
+
+	if !psr.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	if decl.Params = psr.parseParams(); decl.Params == nil {
+		return nil
+	}
+
+	if psr.peekTokenIs(token.ARROW) {
+		psr.nextToken()
+		psr.nextToken()
+
+		if decl.ReturnType = psr.parseType(); decl.ReturnType == nil {
+			return nil
+		}
+	}
+
+	if !psr.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	if decl.Body = psr.parseBlockStatement(); decl.Body == nil {
+		return nil
+	}
+
+	return decl
+}
+
+// Parse the parameters of a function, the current token is ( and a trailing comma is allowed
+func (psr *Parser) parseParams() []*ast.Param {
+	params := []*ast.Param{}
+
+	for !psr.peekTokenIs(token.RPAREN) {
+		if !psr.expectPeek(token.IDENTIFIER) {
+			return nil
+		}
+
+		param := &ast.Param{Name: &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}}
+
+		if !psr.expectPeek(token.COLON) {
+			return nil
+		}
+
+		psr.nextToken()
+
+		if param.Type = psr.parseType(); param.Type == nil {
+			return nil
+		}
+
+		params = append(params, param)
+
+		if !psr.peekTokenIs(token.COMMA) {
+			break
+		}
+
+		psr.nextToken()
+	}
+
+	if !psr.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return params
+}
+
+// Parse the statements of a block, the current token is { and is left on the closing }
+func (psr *Parser) parseBlockStatement() *ast.BlockStatement {
+	block := &ast.BlockStatement{Token: psr.curToken, Statements: []ast.Statement{}}
+
+	psr.nextToken()
+
+	for !psr.curTokenIs(token.RBRACE) {
+		if psr.curTokenIs(token.EOF) {
+			psr.curError(token.RBRACE)
+			return nil
+		}
+
+		start := psr.braces
+
+		if stmt := psr.parseStatement(); stmt != nil {
+			block.Statements = append(block.Statements, stmt)
+		} else if psr.synchronize(start) {
+			// The broken statement ran into the } closing this block
+			break
+		}
+
+		psr.nextToken()
+	}
+
+	block.Rbrace = psr.curToken
+
+	return block
+}
+
+// Parse a struct, union or enum declaration with the layout attributes in front of it
+func (psr *Parser) parseTypeDecl() ast.Statement {
+	attrs := []*ast.Attribute{}
+
+	for psr.curTokenIs(token.AT) {
+		attr := psr.parseAttribute()
+		if attr == nil {
+			return nil
+		}
+
+		attrs = append(attrs, attr)
+		psr.nextToken()
+	}
+
+	switch psr.curToken.Type {
+	case token.STRUCT:
+		decl := &ast.StructDecl{Token: psr.curToken, Attributes: attrs}
+
+		if decl.Name, decl.Fields = psr.parseFieldDecl(); decl.Fields == nil {
+			return nil
+		}
+
+		decl.Rbrace = psr.curToken
+		return decl
+	case token.UNION:
+		decl := &ast.UnionDecl{Token: psr.curToken, Attributes: attrs}
+
+		if decl.Name, decl.Fields = psr.parseFieldDecl(); decl.Fields == nil {
+			return nil
+		}
+
+		decl.Rbrace = psr.curToken
+		return decl
+	case token.ENUM:
+		decl := psr.parseEnumDecl()
+		if decl == nil {
+			return nil
+		}
+
+		decl.Attributes = attrs
+		return decl
+	}
+
+	psr.errorf(diag.ExpectedDecl, diag.TokenSpan(psr.curToken), "expected struct, union or enum after attributes, got %s instead", psr.curToken.Type)
+	return nil
+}
+
+// Parse @name or @name(args), the current token is @ and is left on the last token of the attribute
+func (psr *Parser) parseAttribute() *ast.Attribute {
+	attr := &ast.Attribute{Token: psr.curToken}
+
+	if !psr.expectPeek(token.IDENTIFIER) {
+		return nil
+	}
+
+	attr.Name = &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}
+
+	if !psr.peekTokenIs(token.LPAREN) {
+		return attr
+	}
+
+	psr.nextToken()
+
+	for !psr.peekTokenIs(token.RPAREN) {
+		psr.nextToken()
+
+		arg := psr.parseExpression(LOWEST)
+		if arg == nil {
+			return nil
+		}
+
+		attr.Args = append(attr.Args, arg)
+
+		if !psr.peekTokenIs(token.COMMA) {
+			break
+		}
+
+		psr.nextToken()
+	}
+
+	if !psr.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	attr.Rparen = psr.curToken
+
+	return attr
+}
+
+// Parse the name and { field: type, ... } of a struct or union, the fields are nil on error
+func (psr *Parser) parseFieldDecl() (*ast.Identifier, []*ast.Field) {
+	if !psr.expectPeek(token.IDENTIFIER) {
+		return nil, nil
+	}
+
+	name := &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}
+
+	if !psr.expectPeek(token.LBRACE) {
+		return nil, nil
+	}
+
+	fields := []*ast.Field{}
+
+	for !psr.peekTokenIs(token.RBRACE) {
+		if !psr.expectPeek(token.IDENTIFIER) {
+			return nil, nil
+		}
+
+		field := &ast.Field{Name: &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}}
+
+		if !psr.expectPeek(token.COLON) {
+			return nil, nil
+		}
+
 		psr.nextToken()
+
+		if field.Type = psr.parseType(); field.Type == nil {
+			return nil, nil
+		}
+
+		fields = append(fields, field)
+
+		if !psr.peekTokenIs(token.COMMA) {
+			break
+		}
+
+		psr.nextToken()
+	}
+
+	if !psr.expectPeek(token.RBRACE) {
+		return nil, nil
+	}
+
+	return name, fields
+}
+
+// Parse enum Name[: type] { Variant [= value], ... }
+func (psr *Parser) parseEnumDecl() *ast.EnumDecl {
+	decl := &ast.EnumDecl{Token: psr.curToken, Variants: []*ast.EnumVariant{}}
+
+	if !psr.expectPeek(token.IDENTIFIER) {
+		return nil
+	}
+
+	decl.Name = &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}
+
+	if psr.peekTokenIs(token.COLON) {
+		psr.nextToken()
+		psr.nextToken()
+
+		if decl.Backing = psr.parseType(); decl.Backing == nil {
+			return nil
+		}
+	}
+
+	if !psr.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	for !psr.peekTokenIs(token.RBRACE) {
+		if !psr.expectPeek(token.IDENTIFIER) {
+			return nil
+		}
+
+		variant := &ast.EnumVariant{Name: &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}}
+
+		if psr.peekTokenIs(token.ASSIGN) {
+			psr.nextToken()
+			psr.nextToken()
+
+			if variant.Value = psr.parseExpression(LOWEST); variant.Value == nil {
+				return nil
+			}
+		}
+
+		decl.Variants = append(decl.Variants, variant)
+
+		if !psr.peekTokenIs(token.COMMA) {
+			break
+		}
+
+		psr.nextToken()
+	}
+
+	if !psr.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	decl.Rbrace = psr.curToken
+
+	return decl
+}
+
+// Parse an if statement, the chain of elif and else is kept on Alternative
+func (psr *Parser) parseIfStatement() *ast.IfStatement {
+	stmt := &ast.IfStatement{Token: psr.curToken}
+
+	psr.nextToken()
+
+	if stmt.Condition = psr.parseExpression(LOWEST); stmt.Condition == nil {
+		return nil
+	}
+
+	if !psr.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	if stmt.Consequence = psr.parseBlockStatement(); stmt.Consequence == nil {
+		return nil
+	}
+
+	switch {
+	case psr.peekTokenIs(token.ELIF):
+		psr.nextToken()
+
+		alt := psr.parseIfStatement()
+		if alt == nil {
+			return nil
+		}
+		stmt.Alternative = alt
+	case psr.peekTokenIs(token.ELSE):
+		psr.nextToken()
+
+		if !psr.expectPeek(token.LBRACE) {
+			return nil
+		}
+
+		alt := psr.parseBlockStatement()
+		if alt == nil {
+			return nil
+		}
+		stmt.Alternative = alt
+	}
+
+	return stmt
+}
+
+// Parse label: followed by the loop it names
+func (psr *Parser) parseLabelledLoop() ast.Statement {
+	label := &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}
+
+	psr.nextToken()
+	psr.nextToken()
+
+	if !psr.curTokenIs(token.LOOP) && !psr.curTokenIs(token.WHILE) && !psr.curTokenIs(token.FOR) {
+		psr.errorf(diag.ExpectedLoop, diag.TokenSpan(psr.curToken), "label %s must be followed by loop, while or for, got %s instead", label.Value, psr.curToken.Type)
+		return nil
+	}
+
+	return psr.parseLoop(label)
+}
+
+// Parse loop, while or for, the label is nil when the loop is not named
+func (psr *Parser) parseLoop(label *ast.Identifier) ast.Statement {
+	name := ""
+	if label != nil {
+		name = label.Value
+	}
+
+	switch psr.curToken.Type {
+	case token.WHILE:
+		stmt := &ast.WhileStatement{Token: psr.curToken, Label: label}
+		psr.nextToken()
+
+		if stmt.Condition = psr.parseExpression(LOWEST); stmt.Condition == nil {
+			return nil
+		}
+
+		if stmt.Body = psr.parseLoopBody(name); stmt.Body == nil {
+			return nil
+		}
+
+		return stmt
+	case token.FOR:
+		return psr.parseForStatement(label)
+	}
+
+	stmt := &ast.LoopStatement{Token: psr.curToken, Label: label}
+
+	if stmt.Body = psr.parseLoopBody(name); stmt.Body == nil {
+		return nil
 	}
 
 	return stmt
 }
 
-// Parse the return statement
+// Parse for name[: type] in iterable { ... }
+func (psr *Parser) parseForStatement(label *ast.Identifier) ast.Statement {
+	stmt := &ast.ForStatement{Token: psr.curToken, Label: label}
+
+	if !psr.expectPeek(token.IDENTIFIER) {
+		return nil
+	}
+
+	stmt.Var = &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}
+
+	if psr.peekTokenIs(token.COLON) {
+		psr.nextToken()
+		psr.nextToken()
+
+		if stmt.VarType = psr.parseType(); stmt.VarType == nil {
+			return nil
+		}
+	}
+
+	if !psr.expectPeek(token.IN) {
+		return nil
+	}
+
+	psr.nextToken()
+
+	if stmt.Iterable = psr.parseExpression(LOWEST); stmt.Iterable == nil {
+		return nil
+	}
+
+	name := ""
+	if label != nil {
+		name = label.Value
+	}
+
+	if stmt.Body = psr.parseLoopBody(name); stmt.Body == nil {
+		return nil
+	}
+
+	return stmt
+}
+
+// Parse the body of a loop, break and continue inside it may use the label
+func (psr *Parser) parseLoopBody(label string) *ast.BlockStatement {
+	if !psr.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	psr.loops = append(psr.loops, label)
+	body := psr.parseBlockStatement()
+	psr.loops = psr.loops[:len(psr.loops)-1]
+
+	return body
+}
+
+// Parse break or continue with an optional label, both must be inside a loop
+func (psr *Parser) parseBranchStatement() ast.Statement {
+	tok := psr.curToken
+	var label *ast.Identifier
+
+	if psr.peekTokenIs(token.IDENTIFIER) {
+		psr.nextToken()
+		label = &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}
+	}
+
+	if !psr.expectPeek(token.SCOLON) {
+		return nil
+	}
+
+	if len(psr.loops) == 0 {
+		psr.errorf(diag.BranchOutsideLoop, diag.TokenSpan(tok), "%s outside of a loop", tok.Literal)
+	} else if label != nil && !psr.hasLoopLabel(label.Value) {
+		psr.errorf(diag.UnknownLabel, diag.TokenSpan(label.Token), "unknown loop label %s", label.Value)
+	}
+
+	if tok.Type == token.BREAK {
+		return &ast.BreakStatement{Token: tok, Label: label}
+	}
+
+	return &ast.ContinueStatement{Token: tok, Label: label}
+}
+
+// Verify one of the enclosing loops has the label
+func (psr *Parser) hasLoopLabel(label string) bool {
+	for _, name := range psr.loops {
+		if name == label {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse the return statement, the value is left nil for a bare return;
 func (psr *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Token: psr.curToken}
 
+	if psr.peekTokenIs(token.SCOLON) {
+		psr.nextToken()
+		return stmt
+	}
+
+	psr.nextToken()
+
+	if stmt.Value = psr.parseExpression(LOWEST); stmt.Value == nil {
+		return nil
+	}
+
+	if !psr.expectPeek(token.SCOLON) {
+		return nil
+	}
+
+	return stmt
+}
+
+// Parse a type starting at the current token, pointers bind last so vol u32* is a pointer to vol u32
+func (psr *Parser) parseType() ast.TypeExpr {
+	typ := psr.parseTypeOperand()
+
+	for typ != nil && psr.peekTokenIs(token.ASTERISK) {
+		psr.nextToken()
+		typ = &ast.PointerType{Token: psr.curToken, Elem: typ}
+	}
+
+	return typ
+}
+
+// Parse a type without trailing pointers
+func (psr *Parser) parseTypeOperand() ast.TypeExpr {
+	switch psr.curToken.Type {
+	case token.VOLITILE:
+		typ := &ast.VolatileType{Token: psr.curToken}
+		psr.nextToken()
+
+		if typ.Elem = psr.parseTypeOperand(); typ.Elem == nil {
+			return nil
+		}
+
+		return typ
+	case token.LBRACK:
+		// The element takes its own pointers, [4]u8* is an array of four pointers
+		typ := &ast.ArrayType{Token: psr.curToken}
+		psr.nextToken()
+
+		if typ.Len = psr.parseExpression(LOWEST); typ.Len == nil {
+			return nil
+		}
+
+		if !psr.expectPeek(token.RBRACK) {
+			return nil
+		}
+
+		psr.nextToken()
+
+		if typ.Elem = psr.parseType(); typ.Elem == nil {
+			return nil
+		}
+
+		return typ
+	case token.IDENTIFIER:
+		return &ast.NamedType{Token: psr.curToken, Name: psr.curToken.Literal}
+	}
+
+	for i := range datatypes {
+		if psr.curTokenIs(datatypes[i]) {
+			return &ast.PrimitiveType{Token: psr.curToken, Name: psr.curToken.Literal}
+		}
+	}
+
+	psr.typeError()
+	return nil
+}
+
+// Parse Expression Statements
+func (psr *Parser) parseExpressionStatement() ast.Statement {
+	stmt := &ast.ExpressionStatment{
+		Token: psr.curToken,
+	}
+
+	if stmt.Expression = psr.parseExpression(LOWEST); stmt.Expression == nil {
+		return nil
+	}
+
+	// An expression followed by = += ++ etc is the target of an assignment
+	if assignOps[psr.peekToken.Type] {
+		assign := psr.parseAssignStatement(stmt.Expression)
+		if assign == nil {
+			return nil
+		}
+
+		if psr.peekTokenIs(token.SCOLON) {
+			psr.nextToken()
+		}
+
+		return assign
+	}
+
+	if psr.peekTokenIs(token.SCOLON) {
+		psr.nextToken()
+	}
+
+	return stmt
+}
+
+// Parse target = value, target op= value, target++ or target--, the peek token is the operator
+func (psr *Parser) parseAssignStatement(target ast.Expression) *ast.AssignStatement {
+	psr.nextToken()
+
+	stmt := &ast.AssignStatement{Token: psr.curToken, Target: target}
+
+	// The target is kept so the rest of the statement is still parsed
+	if !isAssignable(target) {
+		psr.errorf(diag.InvalidAssign, diag.TokenSpan(stmt.Token), "cannot assign to %s", target)
+	}
+
+	if psr.curTokenIs(token.INC) || psr.curTokenIs(token.DEC) {
+		return stmt
+	}
+
 	psr.nextToken()
 
-	// TODO : Skimming expressions until semicolon
-	for !psr.curTokenIs(token.SCOLON) {
-		psr.nextToken()
+	if stmt.Value = psr.parseExpression(LOWEST); stmt.Value == nil {
+		return nil
 	}
 
 	return stmt
 }
 
-// Parse Expression Statements
-func (psr *Parser) parseExpressionStatement() *ast.ExpressionStatment {
-	stmt := &ast.ExpressionStatment{
-		Token: psr.curToken,
-	}
-
-	stmt.Expression = psr.parseExpression(LOWEST)
-
-	if psr.peekTokenIs(token.SCOLON) {
-		psr.nextToken()
+// Verify an expression names a place that can be written
+func isAssignable(expr ast.Expression) bool {
+	switch ast.Unparen(expr).(type) {
+	case *ast.Identifier, *ast.DerefExpression, *ast.IndexExpression, *ast.FieldExpression:
+		return true
 	}
-
-	return stmt
+	return false
 }
 
 // Parse Expression
@@ -195,7 +976,7 @@ func (psr *Parser) parseExpression(precedence int) ast.Expression {
 
 	leftExp := prefix()
 
-	for !psr.peekTokenIs(token.SCOLON) && precedence < psr.peekPrecedence() {
+	for leftExp != nil && !psr.peekTokenIs(token.SCOLON) && precedence < psr.peekPrecedence() {
 		infix := psr.infixParseFns[psr.peekToken.Type]
 
 		if infix == nil {
@@ -218,23 +999,85 @@ func (psr *Parser) parseIdentifier() ast.Expression {
 }
 
 func (psr *Parser) parseIntegerLiteral() ast.Expression {
+	return psr.parseInteger(false)
+}
+
+// Parse an integer literal, negative marks a literal directly behind a unary - so it is range checked with its sign
+func (psr *Parser) parseInteger(negative bool) ast.Expression {
+	body, suffix := token.SplitSuffix(psr.curToken.Literal)
+
 	literal := &ast.IntegerLiteral{
+		Token:  psr.curToken,
+		Suffix: suffix,
+	}
+
+	// Separators only aid reading, the base comes from the prefix
+	base, digits := token.SplitInt(body)
+	value, ok := new(big.Int).SetString(strings.ReplaceAll(digits, "_", ""), base)
+
+	if !ok {
+		psr.errorf(diag.InvalidLiteral, diag.TokenSpan(psr.curToken), "could not parse %q as integer", psr.curToken.Literal)
+		return nil
+	}
+
+	literal.Value = value
+
+	if suffix != "" {
+		psr.checkIntRange(literal, negative, suffix)
+	}
+
+	return literal
+}
+
+func (psr *Parser) parseFloatLiteral() ast.Expression {
+	literal := &ast.FloatLiteral{
 		Token: psr.curToken,
 	}
 
-	value, err := strconv.ParseInt(psr.curToken.Literal, 0, 64)
+	body, suffix := token.SplitSuffix(psr.curToken.Literal)
+	literal.Suffix = suffix
+
+	value, err := strconv.ParseFloat(strings.ReplaceAll(body, "_", ""), 64)
 
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", psr.curToken.Literal)
-		psr.errors = append(psr.errors, msg)
+		psr.errorf(diag.InvalidLiteral, diag.TokenSpan(psr.curToken), "could not parse %q as float", psr.curToken.Literal)
 		return nil
 	}
 
 	literal.Value = value
 
+	if suffix == "f32" && math.Abs(value) > math.MaxFloat32 {
+		psr.errorf(diag.LiteralRange, diag.TokenSpan(psr.curToken), "literal %s does not fit in f32", psr.curToken.Literal)
+	}
+
 	return literal
 }
 
+// The lexer has already checked the escapes, the quotes are removed before decoding
+func (psr *Parser) parseCharLiteral() ast.Expression {
+	lit := psr.curToken.Literal
+	value, err := lexer.Unescape(lit[1 : len(lit)-1])
+
+	if err != nil || len(value) != 1 {
+		psr.errorf(diag.InvalidLiteral, diag.TokenSpan(psr.curToken), "could not parse %s as char", lit)
+		return nil
+	}
+
+	return &ast.CharLiteral{Token: psr.curToken, Value: value[0]}
+}
+
+func (psr *Parser) parseStringLiteral() ast.Expression {
+	lit := psr.curToken.Literal
+	value, err := lexer.Unescape(lit[1 : len(lit)-1])
+
+	if err != nil {
+		psr.errorf(diag.InvalidLiteral, diag.TokenSpan(psr.curToken), "could not parse %s as string", lit)
+		return nil
+	}
+
+	return &ast.StringLiteral{Token: psr.curToken, Value: value}
+}
+
 func (psr *Parser) parsePrefixExpression() ast.Expression {
 	exp := &ast.PrefixExpression{
 		Token:    psr.curToken,
@@ -243,14 +1086,53 @@ func (psr *Parser) parsePrefixExpression() ast.Expression {
 
 	psr.nextToken()
 
-	exp.Right = psr.parseExpression(PREFIX)
+	// A negative literal is range checked with its sign, so -128i8 fits
+	if exp.Operator == "-" && psr.curTokenIs(token.INT) {
+		exp.Right = psr.parseInteger(true)
+	} else {
+		exp.Right = psr.parseExpression(PREFIX)
+	}
+
+	if exp.Right == nil {
+		return nil
+	}
 
 	return exp
 }
 
+// Parse *operand, reading through a pointer
+func (psr *Parser) parseDerefExpression() ast.Expression {
+	expr := &ast.DerefExpression{Token: psr.curToken}
+
+	psr.nextToken()
+
+	if expr.Operand = psr.parseExpression(PREFIX); expr.Operand == nil {
+		return nil
+	}
+
+	return expr
+}
+
+// Parse &operand, taking the address of a place in memory
+func (psr *Parser) parseAddressOf() ast.Expression {
+	expr := &ast.AddressOf{Token: psr.curToken}
+
+	psr.nextToken()
+
+	if expr.Operand = psr.parseExpression(PREFIX); expr.Operand == nil {
+		return nil
+	}
+
+	return expr
+}
+
 func (psr *Parser) noPrefixParseFnError(tokenType token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function found for %s found", tokenType)
-	psr.errors = append(psr.errors, msg)
+	// Illegal tokens have already been reported by the lexer
+	if tokenType == token.ILLEGAL {
+		return
+	}
+
+	psr.errorf(diag.ExpectedExpression, diag.TokenSpan(psr.curToken), "no prefix parse function found for %s found", tokenType)
 }
 
 // Infix Expressions
@@ -263,7 +1145,220 @@ func (psr *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 
 	prec := psr.curPrecedence()
 	psr.nextToken()
-	expr.Right = psr.parseExpression(prec)
+
+	if expr.Right = psr.parseExpression(prec); expr.Right == nil {
+		return nil
+	}
+
+	return expr
+}
+
+// Parse function(args, ...), the current token is ( and a trailing comma is allowed
+func (psr *Parser) parseCallExpression(function ast.Expression) ast.Expression {
+	expr := &ast.CallExpression{Token: psr.curToken, Function: function, Arguments: []ast.Expression{}}
+
+	for !psr.peekTokenIs(token.RPAREN) {
+		psr.nextToken()
+
+		arg := psr.parseExpression(LOWEST)
+		if arg == nil {
+			return nil
+		}
+
+		expr.Arguments = append(expr.Arguments, arg)
+
+		if !psr.peekTokenIs(token.COMMA) {
+			break
+		}
+
+		psr.nextToken()
+	}
+
+	if !psr.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	expr.Rparen = psr.curToken
+
+	return expr
+}
+
+// Parse left[index], the current token is [
+func (psr *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	expr := &ast.IndexExpression{Token: psr.curToken, Left: left}
+
+	psr.nextToken()
+
+	if expr.Index = psr.parseExpression(LOWEST); expr.Index == nil {
+		return nil
+	}
+
+	if !psr.expectPeek(token.RBRACK) {
+		return nil
+	}
+
+	expr.Rbrack = psr.curToken
+
+	return expr
+}
+
+// Parse left.field, the current token is .
+func (psr *Parser) parseFieldExpression(left ast.Expression) ast.Expression {
+	expr := &ast.FieldExpression{Token: psr.curToken, Left: left}
+
+	if !psr.expectPeek(token.IDENTIFIER) {
+		return nil
+	}
+
+	expr.Field = &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}
+
+	return expr
+}
+
+// Parse start..end or start..=end, ranges do not chain
+func (psr *Parser) parseRangeExpression(left ast.Expression) ast.Expression {
+	expr := &ast.RangeExpression{
+		Token:     psr.curToken,
+		Low:       left,
+		Inclusive: psr.curTokenIs(token.RANGE_INCL),
+	}
+
+	prec := psr.curPrecedence()
+	psr.nextToken()
+
+	if expr.High = psr.parseExpression(prec); expr.High == nil {
+		return nil
+	}
+
+	if psr.peekTokenIs(token.RANGE) || psr.peekTokenIs(token.RANGE_INCL) {
+		psr.errorf(diag.ChainedRange, diag.TokenSpan(psr.peekToken), "ranges cannot be chained")
+	}
+
+	return expr
+}
+
+// Parse match subject { pattern | pattern => body, default => body }
+func (psr *Parser) parseMatchExpression() ast.Expression {
+	expr := &ast.MatchExpression{Token: psr.curToken}
+
+	psr.nextToken()
+
+	if expr.Subject = psr.parseExpression(LOWEST); expr.Subject == nil {
+		return nil
+	}
+
+	if !psr.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	for !psr.peekTokenIs(token.RBRACE) {
+		if psr.peekTokenIs(token.EOF) {
+			psr.peekError(token.RBRACE)
+			return nil
+		}
+
+		psr.nextToken()
+
+		arm := psr.parseMatchArm()
+		if arm == nil {
+			return nil
+		}
+
+		expr.Arms = append(expr.Arms, arm)
+
+		// Arms are separated by commas, optional after a block
+		if psr.peekTokenIs(token.COMMA) {
+			psr.nextToken()
+		} else if !psr.peekTokenIs(token.RBRACE) && !psr.curTokenIs(token.RBRACE) {
+			psr.peekError(token.COMMA)
+			return nil
+		}
+	}
+
+	psr.nextToken()
+	expr.Rbrace = psr.curToken
+
+	return expr
+}
+
+// Parse a single arm, the current token is the first token of its patterns
+func (psr *Parser) parseMatchArm() *ast.MatchArm {
+	arm := &ast.MatchArm{Token: psr.curToken}
+
+	if psr.curTokenIs(token.DEFAULT) {
+		arm.Default = true
+	} else {
+		for {
+			pattern := psr.parsePattern()
+			if pattern == nil {
+				return nil
+			}
+
+			arm.Patterns = append(arm.Patterns, pattern)
+
+			if !psr.peekTokenIs(token.OR) {
+				break
+			}
+
+			psr.nextToken()
+			psr.nextToken()
+		}
+	}
+
+	if !psr.expectPeek(token.MATCH_BRANCH) {
+		return nil
+	}
+
+	psr.nextToken()
+
+	if psr.curTokenIs(token.LBRACE) {
+		if arm.Body = psr.parseBlockStatement(); arm.Body == nil {
+			return nil
+		}
+		return arm
+	}
+
+	body := &ast.ExpressionStatment{Token: psr.curToken}
+	if body.Expression = psr.parseExpression(LOWEST); body.Expression == nil {
+		return nil
+	}
+	arm.Body = body
+
+	if assignOps[psr.peekToken.Type] {
+		assign := psr.parseAssignStatement(body.Expression)
+		if assign == nil {
+			return nil
+		}
+		arm.Body = assign
+	}
+
+	return arm
+}
+
+// Parse a pattern, a value or a range of values, | is left to separate alternatives
+func (psr *Parser) parsePattern() ast.Expression {
+	pattern := psr.parseExpression(PREFIX)
+	if pattern == nil {
+		return nil
+	}
+
+	if !psr.peekTokenIs(token.RANGE) && !psr.peekTokenIs(token.RANGE_INCL) {
+		return pattern
+	}
+
+	psr.nextToken()
+
+	expr := &ast.RangeExpression{
+		Token:     psr.curToken,
+		Low:       pattern,
+		Inclusive: psr.curTokenIs(token.RANGE_INCL),
+	}
+
+	psr.nextToken()
+
+	if expr.High = psr.parseExpression(PREFIX); expr.High == nil {
+		return nil
+	}
 
 	return expr
 }
@@ -276,15 +1371,71 @@ func (psr *Parser) parseBoolean() ast.Expression {
 }
 
 func (psr *Parser) parseGroupExpression() ast.Expression {
+	group := &ast.GroupExpression{Lparen: psr.curToken}
+
 	psr.nextToken()
 
-	exp := psr.parseExpression(LOWEST)
+	if group.Expression = psr.parseExpression(LOWEST); group.Expression == nil {
+		return nil
+	}
 
 	if !psr.expectPeek(token.RPAREN) {
 		return nil
 	}
 
-	return exp
+	group.Rparen = psr.curToken
+	return group
+}
+
+// Add an error if the integer literal, negated when behind a unary -, does not fit the named type
+func (psr *Parser) checkIntRange(literal *ast.IntegerLiteral, negative bool, name string) {
+	min, max, ok := token.IntRange(name)
+	if !ok {
+		psr.errorf(diag.LiteralType, diag.TokenSpan(literal.Token), "integer literal %s cannot have type %s", literal.Token.Literal, name)
+		return
+	}
+
+	value := literal.Value
+	if negative {
+		value = new(big.Int).Neg(value)
+	}
+
+	if value.Cmp(min) < 0 || value.Cmp(max) > 0 {
+		psr.errorf(diag.LiteralRange, diag.TokenSpan(literal.Token), "literal %s does not fit in %s, range is %s..=%s", value, name, min, max)
+	}
+}
+
+// Add an error if an integer literal value does not fit the declared type
+func (psr *Parser) checkLiteralType(value ast.Expression, typ ast.TypeExpr) {
+	prim, ok := typ.(*ast.PrimitiveType)
+	if !ok {
+		return
+	}
+
+	negative := false
+	if prefix, ok := ast.Unparen(value).(*ast.PrefixExpression); ok && prefix.Operator == "-" {
+		value = prefix.Right
+		negative = true
+	}
+
+	literal, ok := ast.Unparen(value).(*ast.IntegerLiteral)
+	if !ok {
+		return
+	}
+
+	if _, _, ok := token.IntRange(prim.Name); !ok {
+		return
+	}
+
+	if literal.Suffix != "" && literal.Suffix != prim.Name {
+		psr.errorf(diag.LiteralType, diag.TokenSpan(literal.Token), "literal %s has type %s, expected %s", literal.Token.Literal, literal.Suffix, prim.Name)
+		return
+	}
+
+	// Suffixed literals were already checked when parsed
+	if literal.Suffix == "" {
+		psr.checkIntRange(literal, negative, prim.Name)
+	}
 }
 
 // COMMON FUNCTIONS
@@ -309,19 +1460,6 @@ func (psr *Parser) expectPeek(tok token.TokenType) bool {
 	}
 }
 
-// Verify all allowed types for all data
-func (psr *Parser) expectPeekDataType() bool {
-	for i := range datatypes {
-		if psr.peekTokenIs(datatypes[i]) {
-			psr.nextToken()
-			return true
-		}
-	}
-
-	psr.peekDataError()
-	return false
-}
-
 // Peek Precedence
 func (psr *Parser) peekPrecedence() int {
 	if p, ok := precedences[psr.peekToken.Type]; ok {
@@ -342,25 +1480,88 @@ func (psr *Parser) curPrecedence() int {
 
 // Return errors from data structure
 func (psr *Parser) Errors() []string {
-	return psr.errors
+	return psr.diags.Strings()
+}
+
+// Return the diagnostics of the lexer and parser, in the order they were found
+func (psr *Parser) Diagnostics() diag.List {
+	return psr.diags
+}
+
+// Add an error on a span
+func (psr *Parser) errorf(code diag.Code, span diag.Span, format string, args ...interface{}) {
+	psr.diags = append(psr.diags, diag.Errorf(code, span, format, args...))
+}
+
+// Add an error when the current token is not the expected one
+func (psr *Parser) curError(tok token.TokenType) {
+	psr.errorf(diag.UnexpectedToken, diag.TokenSpan(psr.curToken), "expected %s, got %s instead", tok, psr.curToken.Type)
 }
 
 // Add an error for the expected error
 func (psr *Parser) peekError(tok token.TokenType) {
-	msg := fmt.Sprintf("expected next rune to be %s, got %s instead", tok, psr.peekToken.Type)
-	psr.errors = append(psr.errors, msg)
+	d := diag.Errorf(diag.UnexpectedToken, diag.TokenSpan(psr.peekToken), "expected next rune to be %s, got %s instead", tok, psr.peekToken.Type)
+
+	// A missing ; is the most common mistake, suggest it straight after the statement
+	if tok == token.SCOLON {
+		d = d.WithFix(diag.At(psr.curToken.End), ";", "add ; to end the statement")
+	}
+
+	psr.diags = append(psr.diags, d)
+}
+
+// Add an error if the current token cannot start a type
+func (psr *Parser) typeError() {
+	psr.errorf(diag.ExpectedType, diag.TokenSpan(psr.curToken), "expected type %v, vol, [ or a type name, got %s instead", datatypes, psr.curToken.Type)
 }
 
-// Add an error if the data type is not found
-func (psr *Parser) peekDataError() {
-	msg := fmt.Sprintf("expected next rune to be %v, got %s instead", datatypes, psr.peekToken.Type)
-	psr.errors = append(psr.errors, msg)
+// Skip what is left of a statement that failed to parse, start is the brace depth it began at.
+// The current token is left on its last token, the ; or the } of a block it opened, or just
+// before the next statement. Returns true when instead the } closing the enclosing block is reached
+func (psr *Parser) synchronize(start int) bool {
+	for !psr.curTokenIs(token.EOF) {
+		if psr.curTokenIs(token.RBRACE) && psr.braces < start {
+			return true
+		}
+
+		if psr.braces == start {
+			if psr.curTokenIs(token.SCOLON) || psr.curTokenIs(token.RBRACE) {
+				return false
+			}
+
+			if psr.peekTokenIs(token.RBRACE) || psr.peekTokenIs(token.EOF) || statementStarts[psr.peekToken.Type] {
+				return false
+			}
+		}
+
+		psr.nextToken()
+	}
+
+	return false
 }
 
 // Move on to the next token, and peek ahead the following token
 func (psr *Parser) nextToken() {
 	psr.curToken = psr.peekToken
 	psr.peekToken = psr.lex.NextToken()
+
+	switch psr.curToken.Type {
+	case token.LBRACE:
+		psr.braces++
+	case token.RBRACE:
+		psr.braces--
+	}
+
+	// Comments are kept aside so they never reach the grammar
+	for psr.peekToken.Type == token.COMMENT {
+		psr.comments = append(psr.comments, &ast.Comment{Token: psr.peekToken, Text: psr.peekToken.Literal})
+		psr.peekToken = psr.lex.NextToken()
+	}
+
+	// Carry over any errors raised by the lexer
+	lexErrors := psr.lex.Diagnostics()
+	psr.diags = append(psr.diags, lexErrors[psr.lexErrors:]...)
+	psr.lexErrors = len(lexErrors)
 }
 
 // Register a prefix for an expression
@@ -1,11 +1,17 @@
 package parser
 
 import (
+	"bytes"
+	"flag"
 	"fmt"
+	"math/big"
+	"os"
 	"testing"
 
 	"github.com/Urvirith/bearlang/src/ast"
+	"github.com/Urvirith/bearlang/src/diag"
 	"github.com/Urvirith/bearlang/src/lexer"
+	"github.com/Urvirith/bearlang/src/token"
 )
 
 func TestLetStatementsPass(t *testing.T) {
@@ -48,55 +54,733 @@ func TestLetStatementsFail(t *testing.T) {
 	let x: u16 5;
 	let : u32 = 10;
 	let 838383;
+	let foobar = 1;
 	`
 	lex := lexer.New(input)
 	psr := New(lex)
 	prg := psr.ParseProgram()
+
+	if prg == nil {
+		t.Fatalf("ParseProgram() returned null")
+	}
+
+	expected := []string{
+		"2:13: expected next rune to be =, got INT instead",
+		"3:6: expected next rune to be IDENTIFIER, got : instead",
+		"4:6: expected next rune to be IDENTIFIER, got INT instead",
+	}
+
+	if len(psr.Errors()) != len(expected) {
+		t.Fatalf("parser does not have %d errors. got=%q", len(expected), psr.Errors())
+	}
+
+	for i, msg := range expected {
+		if psr.Errors()[i] != msg {
+			t.Errorf("errors[%d] - expected=%q. got=%q", i, msg, psr.Errors()[i])
+		}
+	}
+
+	// Parsing carries on after each broken statement
+	if len(prg.Statements) != 1 {
+		t.Fatalf("program.Statements does not have 1 statement. got=%d", len(prg.Statements))
+	}
+
+	testLetStatement(t, prg.Statements[0], "foobar")
+}
+
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input    string
+		errors   []string
+		expected string
+	}{
+		{"let x = 5", []string{"1:10: expected next rune to be ;, got EOF instead"}, ""},
+		{"let x = ; let y = 1;", []string{"1:9: no prefix parse function found for ; found"}, "let y = 1;"},
+		{"fn f() { let = 1; let y = 2; } let z = 3;", []string{"1:14: expected next rune to be IDENTIFIER, got = instead"}, "fn f() { let y = 2; }let z = 3;"},
+		{"fn f() { let x = } let z = 3;", []string{"1:18: no prefix parse function found for } found"}, "fn f() {}let z = 3;"},
+		{"fn f() { match x { 0 => } let y = 1; } let z = 3;", []string{"1:25: no prefix parse function found for } found"}, "fn f() { let y = 1; }let z = 3;"},
+		{"if == { a; } let y = 1;", []string{"1:4: no prefix parse function found for == found"}, "let y = 1;"},
+		{"struct S { a u8 } let y = 1;", []string{"1:14: expected next rune to be :, got U8 instead"}, "let y = 1;"},
+		{"let a = (1 + ; let b = 2;", []string{"1:14: no prefix parse function found for ; found"}, "let b = 2;"},
+		{"x = ; y = 2;", []string{"1:5: no prefix parse function found for ; found"}, "y = 2;"},
+		{
+			"fn f() { if x { let = 1; } else { return 1 } let y = 2; }",
+			[]string{"1:21: expected next rune to be IDENTIFIER, got = instead", "1:44: expected next rune to be ;, got } instead"},
+			"fn f() { if x {} else {} let y = 2; }",
+		},
+	}
+
+	for i, tt := range tests {
+		lex := lexer.New(tt.input)
+		psr := New(lex)
+		prg := psr.ParseProgram()
+
+		if len(psr.Errors()) != len(tt.errors) {
+			t.Errorf("tests[%d] - expected errors %q. got=%q", i, tt.errors, psr.Errors())
+			continue
+		}
+
+		for j, msg := range tt.errors {
+			if psr.Errors()[j] != msg {
+				t.Errorf("tests[%d] - errors[%d] expected=%q. got=%q", i, j, msg, psr.Errors()[j])
+			}
+		}
+
+		if prg.String() != tt.expected {
+			t.Errorf("tests[%d] - expected=%q. got=%q", i, tt.expected, prg.String())
+		}
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		input string
+		code  diag.Code
+		span  string
+		fix   string
+	}{
+		{"let x = 5", diag.UnexpectedToken, "1:10-1:10", "1:10 ;"},
+		{"let x = ;", diag.ExpectedExpression, "1:9-1:10", ""},
+		{"let y: = 1;", diag.ExpectedType, "1:8-1:9", ""},
+		{"break;", diag.BranchOutsideLoop, "1:1-1:6", ""},
+		{"let z = 0x;", diag.InvalidNumber, "1:9-1:11", ""},
+	}
+
+	for i, tt := range tests {
+		psr := New(lexer.New(tt.input))
+		psr.ParseProgram()
+
+		diags := psr.Diagnostics()
+		if len(diags) == 0 {
+			t.Errorf("tests[%d] - expected a diagnostic for %q", i, tt.input)
+			continue
+		}
+
+		d := diags[0]
+
+		if d.Severity != diag.Error {
+			t.Errorf("tests[%d] - severity wrong. expected=error, got=%s", i, d.Severity)
+		}
+
+		if d.Code != tt.code {
+			t.Errorf("tests[%d] - code wrong. expected=%q, got=%q (%s)", i, tt.code, d.Code, d)
+		}
+
+		span := fmt.Sprintf("%s-%s", d.Span.Start, d.Span.End)
+		if span != tt.span {
+			t.Errorf("tests[%d] - span wrong. expected=%q, got=%q", i, tt.span, span)
+		}
+
+		fix := ""
+		if d.Fix != nil {
+			fix = fmt.Sprintf("%s %s", d.Fix.Span.Start, d.Fix.Replacement)
+		}
+
+		if fix != tt.fix {
+			t.Errorf("tests[%d] - fix wrong. expected=%q, got=%q", i, tt.fix, fix)
+		}
+	}
+}
+
+func TestNodeSpans(t *testing.T) {
+	tests := []struct {
+		input    string
+		node     string // Type of the first node of the program with that type
+		expected string
+	}{
+		{"let x: u8 = a + b * 2;", "*ast.LetStatement", "1:1-1:22"},
+		{"let x: u8 = a + b * 2;", "*ast.InfixExpression", "1:13-1:22"},
+		{"let x = (a + b) * c;", "*ast.InfixExpression", "1:9-1:20"},
+		{"let x = (a + b) * c;", "*ast.GroupExpression", "1:9-1:16"},
+		{"let x = ((a));", "*ast.LetStatement", "1:1-1:14"},
+		{"let x: vol u32* = p;", "*ast.PointerType", "1:8-1:16"},
+		{"let x: [4]u8 = p;", "*ast.ArrayType", "1:8-1:13"},
+		{"ext fn f(a: u8) -> u8 {\n\treturn a;\n}", "*ast.FunctionDecl", "1:1-3:2"},
+		{"ext fn f(a: u8) -> u8 {\n\treturn a;\n}", "*ast.Param", "1:10-1:15"},
+		{"ext fn f(a: u8) -> u8 {\n\treturn a;\n}", "*ast.ReturnStatement", "2:2-2:10"},
+		{"@packed @align(4) struct S { a: u8 }", "*ast.StructDecl", "1:1-1:37"},
+		{"@packed @align(4) struct S { a: u8 }", "*ast.Attribute", "1:1-1:8"},
+		{"@align(4) union U { a: u8, b: u16 }", "*ast.Attribute", "1:1-1:10"},
+		{"enum Mode: u8 { Idle, Run = 2 }", "*ast.EnumVariant", "1:17-1:21"},
+		{"outer: loop { break outer; }", "*ast.LoopStatement", "1:1-1:29"},
+		{"outer: loop { break outer; }", "*ast.BreakStatement", "1:15-1:26"},
+		{"if a { b; } else { c; }", "*ast.IfStatement", "1:1-1:24"},
+		{"for i in 0..=9 { x; }", "*ast.RangeExpression", "1:10-1:15"},
+		{"count += 1;", "*ast.AssignStatement", "1:1-1:11"},
+		{"count++;", "*ast.AssignStatement", "1:1-1:8"},
+		{"f(a, b)[i].c;", "*ast.FieldExpression", "1:1-1:13"},
+		{"f(a, b)[i].c;", "*ast.IndexExpression", "1:1-1:11"},
+		{"f(a, b)[i].c;", "*ast.CallExpression", "1:1-1:8"},
+		{"*&x;", "*ast.DerefExpression", "1:1-1:4"},
+		{"let y = match x { 0 => 1, default => { 2; } };", "*ast.MatchExpression", "1:9-1:46"},
+		{"let y = match x { 0 => 1, default => { 2; } };", "*ast.MatchArm", "1:19-1:25"},
+	}
+
+	for i, tt := range tests {
+		psr := New(lexer.New(tt.input))
+		prg := psr.ParseProgram()
+		checkParserErrors(t, psr)
+
+		var found ast.Node
+		ast.Inspect(prg, func(node ast.Node) bool {
+			if found == nil && node != nil && fmt.Sprintf("%T", node) == tt.node {
+				found = node
+			}
+			return found == nil
+		})
+
+		if found == nil {
+			t.Errorf("tests[%d] - no %s in %q", i, tt.node, tt.input)
+			continue
+		}
+
+		span := fmt.Sprintf("%s-%s", found.Pos(), found.End())
+		if span != tt.expected {
+			t.Errorf("tests[%d] - %s %q span wrong. expected=%s, got=%s", i, tt.node, found, tt.expected, span)
+		}
+	}
+}
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestGoldenDumps(t *testing.T) {
+	src, err := os.ReadFile("../../test/main.bl")
+	if err != nil {
+		t.Fatalf("reading test/main.bl: %s", err)
+	}
+
+	psr := New(lexer.NewFile("main.bl", string(src)))
+	prg := psr.ParseProgram()
+	checkParserErrors(t, psr)
+
+	data, err := ast.EncodeJSON(prg)
+	if err != nil {
+		t.Fatalf("EncodeJSON: %s", err)
+	}
+
+	tests := []struct {
+		golden string
+		dump   []byte
+	}{
+		{"testdata/main.json", append(data, '\n')},
+		{"testdata/main.sexpr", []byte(ast.SExpr(prg) + "\n")},
+	}
+
+	for _, tt := range tests {
+		if *update {
+			if err := os.WriteFile(tt.golden, tt.dump, 0644); err != nil {
+				t.Fatalf("writing %s: %s", tt.golden, err)
+			}
+			continue
+		}
+
+		expected, err := os.ReadFile(tt.golden)
+		if err != nil {
+			t.Fatalf("reading %s: %s, run go test -update to create it", tt.golden, err)
+		}
+
+		if !bytes.Equal(tt.dump, expected) {
+			t.Errorf("%s does not match, run go test -update if the change is intended", tt.golden)
+		}
+	}
+
+	// Decoding gives back the same tree
+	decoded, err := ast.DecodeJSON(data)
+	if err != nil {
+		t.Fatalf("DecodeJSON: %s", err)
+	}
+
+	if decoded.String() != prg.String() {
+		t.Errorf("decoded program differs from the parsed one. got=%q", decoded.String())
+	}
+
+	// Every field is written out, so matching JSON means matching trees
+	again, err := ast.EncodeJSON(decoded)
+	if err != nil || !bytes.Equal(again, data) {
+		t.Errorf("decoded program does not encode to the same JSON, err=%v", err)
+	}
+}
+
+func testLetStatement(t *testing.T, stmt ast.Statement, name string) bool {
+	if stmt.TokenLiteral() != "let" {
+		t.Errorf("stmt.TokenLiteral not 'let', got: %q", stmt.TokenLiteral())
+		return false
+	}
+
+	letStmt, ok := stmt.(*ast.LetStatement)
+
+	if !ok {
+		t.Errorf("letStmt.Name.Value not: '%s', got: %s", name, letStmt.Name.Value)
+		return false
+	}
+
+	if letStmt.Name.Value != name {
+		t.Errorf("letStmt.Name not: '%s', got: %s", name, letStmt.Name)
+		return false
+	}
+
+	return true
+}
+
+func TestLetStatementValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		name     string
+		typeName string
+		value    string
+	}{
+		{"let x: u16 = 5;", "x", "u16", "5"},
+		{"let y = x + 2 * 3;", "y", "", "(x + (2 * 3))"},
+		{"let mask: i32 = -(1);", "mask", "i32", "(-1)"},
+		{"let ok: bool = true;", "ok", "bool", "true"},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		psr := New(lex)
+		prg := psr.ParseProgram()
+		checkParserErrors(t, psr)
+
+		if len(prg.Statements) != 1 {
+			t.Fatalf("program.Statements does not have 1 statement. got=%d", len(prg.Statements))
+		}
+
+		if !testLetStatement(t, prg.Statements[0], tt.name) {
+			return
+		}
+
+		stmt := prg.Statements[0].(*ast.LetStatement)
+
+		if tt.typeName == "" && stmt.Type != nil {
+			t.Errorf("%s: stmt.Type not nil. got=%s", tt.input, stmt.Type)
+		}
+
+		if tt.typeName != "" && (stmt.Type == nil || stmt.Type.String() != tt.typeName) {
+			t.Errorf("%s: stmt.Type not %s. got=%v", tt.input, tt.typeName, stmt.Type)
+		}
+
+		if stmt.Value == nil || stmt.Value.String() != tt.value {
+			t.Errorf("%s: stmt.Value not %s. got=%v", tt.input, tt.value, stmt.Value)
+		}
+	}
+}
+
+func TestLetStatementRange(t *testing.T) {
+	tests := []struct {
+		input  string
+		errors int
+	}{
+		{"let x: u8 = 255;", 0},
+		{"let x: u8 = 256;", 1},
+		{"let x: i8 = -128;", 0},
+		{"let x: i8 = -129;", 1},
+		{"let x: u64 = 18446744073709551615;", 0},
+		{"let x: u16 = 5u8;", 1},
+		{"let x: f32 = 838383;", 0},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		psr := New(lex)
+		psr.ParseProgram()
+
+		if len(psr.Errors()) != tt.errors {
+			t.Errorf("%s: expected %d errors. got=%v", tt.input, tt.errors, psr.Errors())
+		}
+	}
+}
+
+func TestConstStatements(t *testing.T) {
+	input := `
+	const GPIOA_BASE:  u32 = 0x42020000;
+	const GPIOA_MODER: vol u32* = (GPIOA_BASE + 0x00);
+	const COUNT = 4;
+	`
+
+	lex := lexer.New(input)
+	psr := New(lex)
+	prg := psr.ParseProgram()
+	checkParserErrors(t, psr)
+
+	tests := []string{
+		"const GPIOA_BASE: u32 = 0x42020000;",
+		"const GPIOA_MODER: vol u32* = (GPIOA_BASE + 0x00);",
+		"const COUNT = 4;",
+	}
+
+	if len(prg.Statements) != len(tests) {
+		t.Fatalf("program.Statements does not have %d statements. got=%d", len(tests), len(prg.Statements))
+	}
+
+	for i, expected := range tests {
+		stmt, ok := prg.Statements[i].(*ast.ConstStatement)
+
+		if !ok {
+			t.Fatalf("stmt not *ast.ConstStatement. got=%T", prg.Statements[i])
+		}
+
+		if stmt.String() != expected {
+			t.Errorf("stmt.String() not %q. got=%q", expected, stmt.String())
+		}
+	}
+}
+
+func TestFunctionDeclarations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		extern   bool
+		params   int
+	}{
+		{"fn add(a: u32, b: vol u8*) -> u32 { return a; }", "fn add(a: u32, b: vol u8*) -> u32 { return a; }", false, 2},
+		{"ext fn _start() {}", "ext fn _start() {}", true, 0},
+		{"fn one(x: i8,) { let y = x; x; }", "fn one(x: i8) { let y = x; x }", false, 1},
+		{"fn ptr() -> u8* { return 0; }", "fn ptr() -> u8* { return 0; }", false, 0},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		psr := New(lex)
+		prg := psr.ParseProgram()
+		checkParserErrors(t, psr)
+
+		if len(prg.Statements) != 1 {
+			t.Fatalf("program.Statements does not have 1 statement. got=%d", len(prg.Statements))
+		}
+
+		decl, ok := prg.Statements[0].(*ast.FunctionDecl)
+
+		if !ok {
+			t.Fatalf("stmt not *ast.FunctionDecl. got=%T", prg.Statements[0])
+		}
+
+		if decl.String() != tt.expected {
+			t.Errorf("decl.String() not %q. got=%q", tt.expected, decl.String())
+		}
+
+		if decl.IsExtern() != tt.extern {
+			t.Errorf("%s: decl.IsExtern() not %t", tt.input, tt.extern)
+		}
+
+		if len(decl.Params) != tt.params {
+			t.Errorf("%s: decl.Params not %d. got=%d", tt.input, tt.params, len(decl.Params))
+		}
+	}
+}
+
+func TestFunctionDeclarationErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn f(a u32) {}", "1:8: expected next rune to be :, got U32 instead"},
+		{"fn f() -> {}", "1:11: expected type [I8 I16 I32 I64 I128 U8 U16 U32 U64 U128 F32 F64 BOOL], vol, [ or a type name, got { instead"},
+		{"ext f() {}", "1:5: expected next rune to be FUNCTION, got IDENTIFIER instead"},
+		{"fn f() { x;", "1:12: expected }, got EOF instead"},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		psr := New(lex)
+		psr.ParseProgram()
+
+		if len(psr.Errors()) == 0 || psr.Errors()[0] != tt.expected {
+			t.Errorf("%q: expected error %q. got=%q", tt.input, tt.expected, psr.Errors())
+		}
+	}
+}
+
+func TestControlFlowStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"if x == 1 { a; }", "if (x == 1) { a }"},
+		{"if x { a; } else { b; }", "if x { a } else { b }"},
+		{"if x { a; } elif y { b; } elif z {} else { c; }", "if x { a } elif y { b } elif z {} else { c }"},
+		{"loop { break; }", "loop { break; }"},
+		{"while i < 10 { continue; }", "while (i < 10) { continue; }"},
+		{"for n: u32 in 0..1200000 { n; }", "for n: u32 in 0..1200000 { n }"},
+		{"for n in a..=b + 1 {}", "for n in a..=(b + 1) {}"},
+		{"outer: loop { for i in 0..4 { break outer; continue; } }", "outer: loop { for i in 0..4 { break outer; continue; } }"},
+		{"spin: while true { continue spin; }", "spin: while true { continue spin; }"},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		psr := New(lex)
+		prg := psr.ParseProgram()
+		checkParserErrors(t, psr)
+
+		if len(prg.Statements) != 1 {
+			t.Fatalf("%s: program.Statements does not have 1 statement. got=%d", tt.input, len(prg.Statements))
+		}
+
+		if prg.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, prg.String())
+		}
+	}
+}
+
+func TestElifChain(t *testing.T) {
+	input := "if a { x; } elif b { y; } else { z; }"
+
+	lex := lexer.New(input)
+	psr := New(lex)
+	prg := psr.ParseProgram()
+	checkParserErrors(t, psr)
+
+	stmt, ok := prg.Statements[0].(*ast.IfStatement)
+	if !ok {
+		t.Fatalf("stmt not *ast.IfStatement. got=%T", prg.Statements[0])
+	}
+
+	elif, ok := stmt.Alternative.(*ast.IfStatement)
+	if !ok {
+		t.Fatalf("stmt.Alternative not *ast.IfStatement. got=%T", stmt.Alternative)
+	}
+
+	if elif.Token.Type != token.ELIF {
+		t.Errorf("elif.Token not ELIF. got=%s", elif.Token.Type)
+	}
+
+	if _, ok := elif.Alternative.(*ast.BlockStatement); !ok {
+		t.Errorf("elif.Alternative not *ast.BlockStatement. got=%T", elif.Alternative)
+	}
+}
+
+func TestMatchExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		arms     int
+	}{
+		{"match x { 0 => a, 1 | 2 => b, 3..=9 => c, default => d }", "match x { 0 => a, 1 | 2 => b, 3..=9 => c, default => d }", 4},
+		{"match b { true => { x; } false => { y; } }", "match b { true => { x }, false => { y } }", 2},
+		{"let y = match x { -1 => 0, default => x + 1, };", "let y = match x { (-1) => 0, default => (x + 1) };", 2},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		psr := New(lex)
+		prg := psr.ParseProgram()
+		checkParserErrors(t, psr)
+
+		if len(prg.Statements) != 1 {
+			t.Fatalf("%s: program.Statements does not have 1 statement. got=%d", tt.input, len(prg.Statements))
+		}
+
+		if prg.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, prg.String())
+		}
+	}
+}
+
+func TestLoopBranchErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"break;", "1:1: break outside of a loop"},
+		{"fn f() { continue; }", "1:10: continue outside of a loop"},
+		{"loop { break outer; }", "1:14: unknown loop label outer"},
+		{"for i in 0..1..2 {}", "1:14: ranges cannot be chained"},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		psr := New(lex)
+		psr.ParseProgram()
+
+		if len(psr.Errors()) == 0 || psr.Errors()[0] != tt.expected {
+			t.Errorf("%q: expected error %q. got=%q", tt.input, tt.expected, psr.Errors())
+		}
+	}
+}
+
+func TestTypeExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		check    func(ast.TypeExpr) bool
+	}{
+		{"let x: u32 = 0;", "u32", func(typ ast.TypeExpr) bool {
+			_, ok := typ.(*ast.PrimitiveType)
+			return ok
+		}},
+		{"let x: vol u32* = 0;", "vol u32*", func(typ ast.TypeExpr) bool {
+			ptr, ok := typ.(*ast.PointerType)
+			if !ok {
+				return false
+			}
+			_, ok = ptr.Elem.(*ast.VolatileType)
+			return ok
+		}},
+		{"let x: u8** = 0;", "u8**", func(typ ast.TypeExpr) bool {
+			ptr, ok := typ.(*ast.PointerType)
+			if !ok {
+				return false
+			}
+			_, ok = ptr.Elem.(*ast.PointerType)
+			return ok
+		}},
+		{"let x: [4]u8* = 0;", "[4]u8*", func(typ ast.TypeExpr) bool {
+			arr, ok := typ.(*ast.ArrayType)
+			if !ok {
+				return false
+			}
+			_, ok = arr.Elem.(*ast.PointerType)
+			return ok
+		}},
+		{"let x: [BUF_LEN * 2]vol u8 = 0;", "[(BUF_LEN * 2)]vol u8", func(typ ast.TypeExpr) bool {
+			arr, ok := typ.(*ast.ArrayType)
+			if !ok {
+				return false
+			}
+			_, ok = arr.Elem.(*ast.VolatileType)
+			return ok
+		}},
+		{"let x: Gpio* = 0;", "Gpio*", func(typ ast.TypeExpr) bool {
+			ptr, ok := typ.(*ast.PointerType)
+			if !ok {
+				return false
+			}
+			_, ok = ptr.Elem.(*ast.NamedType)
+			return ok
+		}},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		psr := New(lex)
+		prg := psr.ParseProgram()
+		checkParserErrors(t, psr)
+
+		stmt := prg.Statements[0].(*ast.LetStatement)
+
+		if stmt.Type.String() != tt.expected {
+			t.Errorf("%s: stmt.Type not %s. got=%s", tt.input, tt.expected, stmt.Type)
+		}
+
+		if !tt.check(stmt.Type) {
+			t.Errorf("%s: stmt.Type has the wrong shape. got=%T", tt.input, stmt.Type)
+		}
+	}
+}
+
+func TestTypeDeclarations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct Point { x: i32, y: i32 }", "struct Point { x: i32, y: i32 }"},
+		{"@packed @align(4) struct Gpio { moder: vol u32, pins: [16]u8*, }", "@packed @align(4) struct Gpio { moder: vol u32, pins: [16]u8* }"},
+		{"struct Empty {}", "struct Empty {}"},
+		{"union Word { bits: u32, value: f32 }", "union Word { bits: u32, value: f32 }"},
+		{"enum Mode: u8 { Idle, Run = 4, Stop }", "enum Mode: u8 { Idle, Run = 4, Stop }"},
+		{"enum Flag { On = 2 * 4, }", "enum Flag { On = (2 * 4) }"},
+	}
+
+	for i, tt := range tests {
+		lex := lexer.New(tt.input)
+		psr := New(lex)
+		prg := psr.ParseProgram()
+		checkParserErrors(t, psr)
+
+		if len(prg.Statements) != 1 {
+			t.Fatalf("tests[%d] - prg.Statements does not contain 1 statement. got=%d", i, len(prg.Statements))
+		}
+
+		if prg.Statements[0].String() != tt.expected {
+			t.Errorf("tests[%d] - expected=%q. got=%q", i, tt.expected, prg.Statements[0].String())
+		}
+	}
+
+	lex := lexer.New("@align(8) enum Mode: u16 { Idle }")
+	psr := New(lex)
+	prg := psr.ParseProgram()
 	checkParserErrors(t, psr)
 
-	if prg == nil {
-		t.Fatalf("ParseProgram() returned null")
+	decl, ok := prg.Statements[0].(*ast.EnumDecl)
+	if !ok {
+		t.Fatalf("prg.Statements[0] is not *ast.EnumDecl. got=%T", prg.Statements[0])
 	}
 
-	if len(prg.Statements) != 3 {
-		t.Fatalf("program.Statements does not have 3 statements. got=%d", len(prg.Statements))
+	if len(decl.Attributes) != 1 || decl.Attributes[0].Name.Value != "align" {
+		t.Errorf("decl.Attributes not [@align(8)]. got=%v", decl.Attributes)
+	}
+
+	if decl.Backing.String() != "u16" || decl.Variants[0].Value != nil {
+		t.Errorf("decl has the wrong backing or variant. got=%s", decl)
 	}
+}
 
+func TestAssignStatements(t *testing.T) {
 	tests := []struct {
-		expectedIdentifier string
+		input    string
+		operator string
+		expected string
 	}{
-		{"x"},
-		{"y"},
-		{"foobar"},
+		{"x = 5;", "=", "x = 5;"},
+		{"x += y * 2;", "+=", "x += (y * 2);"},
+		{"reg |= 4", "|=", "reg |= 4;"},
+		{"reg &= !mask;", "&=", "reg &= (!mask);"},
+		{"x ^= 1;", "^=", "x ^= 1;"},
+		{"x *= 3; ", "*=", "x *= 3;"},
+		{"x /= 3;", "/=", "x /= 3;"},
+		{"x %= 3;", "%=", "x %= 3;"},
+		{"x <<= 1;", "<<=", "x <<= 1;"},
+		{"x >>= 1;", ">>=", "x >>= 1;"},
+		{"count++;", "++", "count++;"},
+		{"count--", "--", "count--;"},
 	}
 
 	for i, tt := range tests {
-		stmt := prg.Statements[i]
-		if !testLetStatement(t, stmt, tt.expectedIdentifier) {
-			return
+		lex := lexer.New(tt.input)
+		psr := New(lex)
+		prg := psr.ParseProgram()
+		checkParserErrors(t, psr)
+
+		if len(prg.Statements) != 1 {
+			t.Fatalf("tests[%d] - prg.Statements does not contain 1 statement. got=%d", i, len(prg.Statements))
 		}
-	}
-}
 
-func testLetStatement(t *testing.T, stmt ast.Statement, name string) bool {
-	if stmt.TokenLiteral() != "let" {
-		t.Errorf("stmt.TokenLiteral not 'let', got: %q", stmt.TokenLiteral())
-		return false
-	}
+		stmt, ok := prg.Statements[0].(*ast.AssignStatement)
+		if !ok {
+			t.Fatalf("tests[%d] - prg.Statements[0] is not *ast.AssignStatement. got=%T", i, prg.Statements[0])
+		}
 
-	letStmt, ok := stmt.(*ast.LetStatement)
+		if stmt.TokenLiteral() != tt.operator {
+			t.Errorf("tests[%d] - stmt.TokenLiteral not %q. got=%q", i, tt.operator, stmt.TokenLiteral())
+		}
 
-	if !ok {
-		t.Errorf("letStmt.Name.Value not: '%s', got: %s", name, letStmt.Name.Value)
-		return false
+		if stmt.String() != tt.expected {
+			t.Errorf("tests[%d] - expected=%q. got=%q", i, tt.expected, stmt.String())
+		}
 	}
+}
 
-	if letStmt.Name.Value != name {
-		t.Errorf("letStmt.Name not: '%s', got: %s", name, letStmt.Name)
-		return false
+func TestAssignStatementErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"5 = x;", "1:3: cannot assign to 5"},
+		{"a + b += 1;", "1:7: cannot assign to (a + b)"},
+		{"true++;", "1:5: cannot assign to true"},
+		{"fn f(x: u8) { match x { 0 => 1 = 2, default => 0 } }", "1:32: cannot assign to 1"},
 	}
 
-	return true
+	for i, tt := range tests {
+		lex := lexer.New(tt.input)
+		psr := New(lex)
+		psr.ParseProgram()
+
+		errors := psr.Errors()
+		if len(errors) != 1 || errors[0] != tt.expected {
+			t.Errorf("tests[%d] - expected error %q. got=%q", i, tt.expected, errors)
+		}
+	}
 }
 
 func TestReturnStatementsPass(t *testing.T) {
@@ -130,8 +814,69 @@ func TestReturnStatementsPass(t *testing.T) {
 	}
 }
 
+func TestReturnStatementValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"return 5;", "return 5;"},
+		{"return x + y;", "return (x + y);"},
+		{"return;", "return;"},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		psr := New(lex)
+		prg := psr.ParseProgram()
+		checkParserErrors(t, psr)
+
+		if prg.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, prg.String())
+		}
+	}
+}
+
+func TestErrorPositions(t *testing.T) {
+	input := "let x: u16 = 5;\n(5 + x;"
+
+	lex := lexer.NewFile("main.bl", input)
+	psr := New(lex)
+	psr.ParseProgram()
+
+	if len(psr.Errors()) == 0 {
+		t.Fatalf("expected parser errors, got none")
+	}
+
+	expected := "main.bl:2:7: expected next rune to be ), got ; instead"
+	if psr.Errors()[0] != expected {
+		t.Fatalf("error wrong. expected: %q, got: %q", expected, psr.Errors()[0])
+	}
+}
+
+func TestCommentsCollected(t *testing.T) {
+	input := "// header\nx; /* tail */"
+
+	lex := lexer.New(input)
+	lex.EmitComments(true)
+	psr := New(lex)
+	prg := psr.ParseProgram()
+	checkParserErrors(t, psr)
+
+	if len(prg.Statements) != 1 {
+		t.Fatalf("program.Statements does not have 1 statement. got=%d", len(prg.Statements))
+	}
+
+	if len(prg.Comments) != 2 {
+		t.Fatalf("program.Comments does not have 2 comments. got=%d", len(prg.Comments))
+	}
+
+	if prg.Comments[0].Text != "// header" || prg.Comments[1].Text != "/* tail */" {
+		t.Fatalf("comments wrong. got=%q, %q", prg.Comments[0].Text, prg.Comments[1].Text)
+	}
+}
+
 func checkParserErrors(t *testing.T, psr *Parser) {
-	errors := psr.errors
+	errors := psr.Errors()
 
 	if len(errors) == 0 {
 		return
@@ -164,7 +909,7 @@ func TestIdentifierExpression(t *testing.T) {
 		t.Fatalf("program.Statements[0] is not *ast.ExpressionStatment. got=%T", program.Statements[0])
 	}
 
-	testIdentifier(t, stmt.Expression, input)
+	testIdentifier(t, stmt.Expression, "foobar")
 }
 
 func testIdentifier(t *testing.T, exp ast.Expression, val string) bool {
@@ -180,8 +925,8 @@ func testIdentifier(t *testing.T, exp ast.Expression, val string) bool {
 		return false
 	}
 
-	if ident.TokenLiteral() != "foobar" {
-		t.Fatalf("ident.TokenLiteral not %s. got=%s", "foobar", ident.TokenLiteral())
+	if ident.TokenLiteral() != val {
+		t.Fatalf("ident.TokenLiteral not %s. got=%s", val, ident.TokenLiteral())
 		return false
 	}
 
@@ -213,7 +958,7 @@ func TestIntegerLiteralExpression(t *testing.T) {
 		t.Fatalf("expression not *ast.IntegerLiteral. got=%T", stmt.Expression)
 	}
 
-	if literal.Value != 5 {
+	if literal.Value.Cmp(big.NewInt(5)) != 0 {
 		t.Fatalf("ident.Value not %d. got=%d", 5, literal.Value)
 	}
 
@@ -222,6 +967,168 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestIntegerLiteralBases(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"0x42020000;", 0x42020000},
+		{"0x00000003;", 3},
+		{"0b1010_0000;", 0xA0},
+		{"0o17;", 15},
+		{"1_200_000;", 1200000},
+		{"0777;", 777},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		psr := New(lex)
+		program := psr.ParseProgram()
+		checkParserErrors(t, psr)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatment)
+		literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+
+		if !ok {
+			t.Fatalf("expression not *ast.IntegerLiteral. got=%T", stmt.Expression)
+		}
+
+		if literal.Value.Cmp(big.NewInt(tt.expected)) != 0 {
+			t.Errorf("%s: literal.Value not %d. got=%d", tt.input, tt.expected, literal.Value)
+		}
+	}
+}
+
+func TestIntegerLiteralSuffixes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		suffix   string
+	}{
+		{"255u8;", "255", "u8"},
+		{"0xFFFF_FFFF_FFFF_FFFFu64;", "18446744073709551615", "u64"},
+		{"1i128;", "1", "i128"},
+		{"0xFFFF_FFFF_FFFF_FFFF_FFFF_FFFF_FFFF_FFFFu128;", "340282366920938463463374607431768211455", "u128"},
+		{"170141183460469231731687303715884105727;", "170141183460469231731687303715884105727", ""},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		psr := New(lex)
+		program := psr.ParseProgram()
+		checkParserErrors(t, psr)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatment)
+		literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+
+		if !ok {
+			t.Fatalf("expression not *ast.IntegerLiteral. got=%T", stmt.Expression)
+		}
+
+		if literal.Value.String() != tt.expected {
+			t.Errorf("%s: literal.Value not %s. got=%s", tt.input, tt.expected, literal.Value)
+		}
+
+		if literal.Suffix != tt.suffix {
+			t.Errorf("%s: literal.Suffix not %s. got=%s", tt.input, tt.suffix, literal.Suffix)
+		}
+	}
+}
+
+func TestIntegerLiteralRange(t *testing.T) {
+	tests := []struct {
+		input  string
+		errors int
+	}{
+		{"255u8;", 0},
+		{"256u8;", 1},
+		{"-128i8;", 0},
+		{"-129i8;", 1},
+		{"128i8;", 1},
+		{"-1u8;", 1},
+		{"0x1_0000_0000u32;", 1},
+		{"1e39f32;", 1},
+		{"1e39f64;", 0},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		psr := New(lex)
+		psr.ParseProgram()
+
+		if len(psr.Errors()) != tt.errors {
+			t.Errorf("%s: expected %d errors. got=%v", tt.input, tt.errors, psr.Errors())
+		}
+	}
+}
+
+func TestFloatLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"20.0;", 20.0},
+		{"1.5e-3;", 0.0015},
+		{"3e8;", 3e8},
+		{"1_000.25;", 1000.25},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		psr := New(lex)
+		program := psr.ParseProgram()
+		checkParserErrors(t, psr)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatment)
+		literal, ok := stmt.Expression.(*ast.FloatLiteral)
+
+		if !ok {
+			t.Fatalf("expression not *ast.FloatLiteral. got=%T", stmt.Expression)
+		}
+
+		if literal.Value != tt.expected {
+			t.Errorf("%s: literal.Value not %g. got=%g", tt.input, tt.expected, literal.Value)
+		}
+	}
+}
+
+func TestCharAndStringLiterals(t *testing.T) {
+	input := `'A'; '\x7F'; "hello\n\0";`
+
+	lex := lexer.New(input)
+	psr := New(lex)
+	program := psr.ParseProgram()
+	checkParserErrors(t, psr)
+
+	if len(program.Statements) != 3 {
+		t.Fatalf("program.Statements does not have 3 statements. got=%d", len(program.Statements))
+	}
+
+	for i, expected := range []byte{'A', 0x7F} {
+		stmt := program.Statements[i].(*ast.ExpressionStatment)
+		char, ok := stmt.Expression.(*ast.CharLiteral)
+
+		if !ok {
+			t.Fatalf("expression not *ast.CharLiteral. got=%T", stmt.Expression)
+		}
+
+		if char.Value != expected {
+			t.Errorf("char.Value not %d. got=%d", expected, char.Value)
+		}
+	}
+
+	stmt := program.Statements[2].(*ast.ExpressionStatment)
+	str, ok := stmt.Expression.(*ast.StringLiteral)
+
+	if !ok {
+		t.Fatalf("expression not *ast.StringLiteral. got=%T", stmt.Expression)
+	}
+
+	if string(str.Value) != "hello\n\x00" {
+		t.Errorf("str.Value not %q. got=%q", "hello\n\x00", str.Value)
+	}
+}
+
 func TestParsingPrefixExpressions(t *testing.T) {
 	prefixTests := []struct {
 		input        string
@@ -232,6 +1139,7 @@ func TestParsingPrefixExpressions(t *testing.T) {
 		{"-15;", "-", 15},
 		{"!true;", "!", true},
 		{"!false;", "!", false},
+		{"~5;", "~", 5},
 	}
 
 	for _, tt := range prefixTests {
@@ -275,7 +1183,7 @@ func testIntegerLiteral(t *testing.T, il ast.Expression, value int64) bool {
 		return false
 	}
 
-	if integer.Value != value {
+	if integer.Value.Cmp(big.NewInt(value)) != 0 {
 		t.Errorf("integer.Value not %d. got=%d", value, integer.Value)
 		return false
 	}
@@ -303,6 +1211,16 @@ func TestParsingInfixExpressions(t *testing.T) {
 		{"5 < 5;", 5, "<", 5},
 		{"5 == 5;", 5, "==", 5},
 		{"5 != 5;", 5, "!=", 5},
+		{"5 >= 5;", 5, ">=", 5},
+		{"5 <= 5;", 5, "<=", 5},
+		{"5 % 5;", 5, "%", 5},
+		{"5 & 5;", 5, "&", 5},
+		{"5 | 5;", 5, "|", 5},
+		{"5 ^ 5;", 5, "^", 5},
+		{"5 << 5;", 5, "<<", 5},
+		{"5 >> 5;", 5, ">>", 5},
+		{"true && false;", true, "&&", false},
+		{"true || false;", true, "||", false},
 		{"true == true;", true, "==", true},
 		{"true != false;", true, "!=", false},
 		{"false == false;", false, "==", false},
@@ -486,6 +1404,154 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 	}
 }
 
+func TestOperatorPrecedenceLevels(t *testing.T) {
+	// Each level from the loosest binding to the tightest, with the level below it
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// .. binds loosest
+		{"a || b..c", "(a || b)..c"},
+		// || below &&
+		{"a || b && c", "(a || (b && c))"},
+		{"a && b || c", "((a && b) || c)"},
+		// && below ==
+		{"a && b == c", "(a && (b == c))"},
+		// == below <
+		{"a == b < c", "(a == (b < c))"},
+		{"a != b >= c", "(a != (b >= c))"},
+		// < below |
+		{"a <= b | c", "(a <= (b | c))"},
+		// | below ^
+		{"a | b ^ c", "(a | (b ^ c))"},
+		// ^ below &
+		{"a ^ b & c", "(a ^ (b & c))"},
+		// & below <<, bitwise operators bind tighter than comparisons
+		{"a & MASK == 0", "((a & MASK) == 0)"},
+		{"a & b << c", "(a & (b << c))"},
+		// << below +
+		{"a << b + c", "(a << (b + c))"},
+		{"a - b >> c", "((a - b) >> c)"},
+		// + below *
+		{"a + b % c", "(a + (b % c))"},
+		// * below prefix
+		{"~a * b", "((~a) * b)"},
+		// Every level is left associative
+		{"a | b | c", "((a | b) | c)"},
+		{"a << b >> c", "((a << b) >> c)"},
+		{"a % b / c", "((a % b) / c)"},
+		{"a && b && c", "((a && b) && c)"},
+		// Register manipulation
+		{"(~(MASK_2_BIT << (LED_GRN * 2)))", "(~(MASK_2_BIT << (LED_GRN * 2)))"},
+		{"reg & ~(3 << 4) | 1 << 4", "((reg & (~(3 << 4))) | (1 << 4))"},
+	}
+
+	for i, tt := range tests {
+		lex := lexer.New(tt.input)
+		psr := New(lex)
+		prg := psr.ParseProgram()
+		checkParserErrors(t, psr)
+
+		if prg.String() != tt.expected {
+			t.Errorf("tests[%d] - expected=%q. got=%q", i, tt.expected, prg.String())
+		}
+	}
+}
+
+func TestDerefAndAddressOf(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"*reg;", "(*reg)"},
+		{"&x;", "(&x)"},
+		{"**pp;", "(*(*pp))"},
+		{"*a * *b;", "((*a) * (*b))"},
+		{"&*p;", "(&(*p))"},
+		{"*REG & ~MASK;", "((*REG) & (~MASK))"},
+		{"*RCC_AHB2ENR |= (1 << PORTA_AHBEN);", "(*RCC_AHB2ENR) |= (1 << PORTA_AHBEN);"},
+		{"*GPIOC_MODER &= (~(MASK_2_BIT << (LED_GRN * 2)));", "(*GPIOC_MODER) &= (~(MASK_2_BIT << (LED_GRN * 2)));"},
+		{"*count++;", "(*count)++;"},
+	}
+
+	for i, tt := range tests {
+		lex := lexer.New(tt.input)
+		psr := New(lex)
+		prg := psr.ParseProgram()
+		checkParserErrors(t, psr)
+
+		if prg.String() != tt.expected {
+			t.Errorf("tests[%d] - expected=%q. got=%q", i, tt.expected, prg.String())
+		}
+	}
+
+	lex := lexer.New("&x = 1;")
+	psr := New(lex)
+	psr.ParseProgram()
+
+	if errors := psr.Errors(); len(errors) != 1 || errors[0] != "1:4: cannot assign to (&x)" {
+		t.Errorf("expected error %q. got=%q", "1:4: cannot assign to (&x)", errors)
+	}
+}
+
+func TestPostfixExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"add(1, 2 * 3, x)", "add(1, (2 * 3), x)"},
+		{"init()", "init()"},
+		{"write(REG, 1,)", "write(REG, 1)"},
+		{"buf[i + 1]", "(buf[(i + 1)])"},
+		{"grid[y][x]", "((grid[y])[x])"},
+		{"gpio.moder", "gpio.moder"},
+		{"port.pins[3].mode", "(port.pins[3]).mode"},
+		{"handlers[n](arg)", "(handlers[n])(arg)"},
+		{"*regs.moder", "(*regs.moder)"},
+		{"(*regs).moder", "(*regs).moder"},
+		{"-a.b * c[0]", "((-a.b) * (c[0]))"},
+		{"&buf[2]", "(&(buf[2]))"},
+		{"gpio.odr |= 1 << pin;", "gpio.odr |= (1 << pin);"},
+		{"buf[0] = read(uart);", "(buf[0]) = read(uart);"},
+	}
+
+	for i, tt := range tests {
+		lex := lexer.New(tt.input)
+		psr := New(lex)
+		prg := psr.ParseProgram()
+		checkParserErrors(t, psr)
+
+		if prg.String() != tt.expected {
+			t.Errorf("tests[%d] - expected=%q. got=%q", i, tt.expected, prg.String())
+		}
+	}
+
+	lex := lexer.New("add(1, x);")
+	psr := New(lex)
+	prg := psr.ParseProgram()
+	checkParserErrors(t, psr)
+
+	stmt := prg.Statements[0].(*ast.ExpressionStatment)
+	call, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not *ast.CallExpression. got=%T", stmt.Expression)
+	}
+
+	if call.Function.String() != "add" {
+		t.Errorf("call.Function not add. got=%s", call.Function)
+	}
+
+	if len(call.Arguments) != 2 {
+		t.Fatalf("call.Arguments does not contain 2 arguments. got=%d", len(call.Arguments))
+	}
+
+	testIntegerLiteral(t, call.Arguments[0], 1)
+
+	if call.Arguments[1].String() != "x" {
+		t.Errorf("call.Arguments[1] not x. got=%s", call.Arguments[1])
+	}
+}
+
 func TestBooleanLiteral(t *testing.T) {
 	input := "true;"
 	lex := lexer.New(input)
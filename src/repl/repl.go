@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/Urvirith/bearlang/src/diag"
 	"github.com/Urvirith/bearlang/src/lexer"
 	"github.com/Urvirith/bearlang/src/token"
 )
@@ -29,5 +30,7 @@ func Start(in io.Reader, out io.Writer) {
 		for tok := lex.NextToken(); tok.Type != token.EOF; tok = lex.NextToken() {
 			fmt.Printf("%+v\n", tok)
 		}
+
+		diag.NewRenderer(out, line).Render(lex.Diagnostics())
 	}
 }
@@ -0,0 +1,487 @@
+package resolver
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Urvirith/bearlang/src/ast"
+	"github.com/Urvirith/bearlang/src/diag"
+	"github.com/Urvirith/bearlang/src/token"
+)
+
+// Structure defining the Resolver, binds each name used to the declaration it refers to
+type Resolver struct {
+	prg      *ast.Program
+	module   *Scope
+	scope    *Scope                       // Innermost scope open
+	stack    []frame                      // Nodes whose children are being walked
+	scopes   map[ast.Node]*Scope          // Scope opened by each node
+	uses     map[*ast.Identifier]*Symbol  // Declaration of each name used
+	types    map[*ast.NamedType]*Symbol   // Declaration of each type name used
+	variants map[*ast.EnumVariant]*Symbol // Symbol of each variant, also those sharing a name with another enum
+	diags    diag.List
+}
+
+// Create new instance for a parsed program
+func New(prg *ast.Program) *Resolver {
+	return &Resolver{
+		prg:      prg,
+		scopes:   make(map[ast.Node]*Scope),
+		uses:     make(map[*ast.Identifier]*Symbol),
+		types:    make(map[*ast.NamedType]*Symbol),
+		variants: make(map[*ast.EnumVariant]*Symbol),
+	}
+}
+
+// Resolve every name of the program and return the errors found
+func (res *Resolver) Resolve() []string {
+	res.module = res.openScope(Module, res.prg)
+
+	// Everything declared at the top of the program can be used before it is declared
+	for _, stmt := range res.prg.Statements {
+		res.declareStatement(stmt)
+	}
+
+	ast.Walk(res, res.prg)
+	res.closeScope()
+
+	return res.diags.Strings()
+}
+
+// Return errors found while resolving
+func (res *Resolver) Errors() []string {
+	return res.diags.Strings()
+}
+
+// Return the diagnostics found while resolving
+func (res *Resolver) Diagnostics() diag.List {
+	return res.diags
+}
+
+// Scope of the whole program
+func (res *Resolver) Module() *Scope {
+	return res.module
+}
+
+// Scope opened by a program, function, block or loop, nil for any other node
+func (res *Resolver) ScopeOf(node ast.Node) *Scope {
+	return res.scopes[node]
+}
+
+// Declaration a name refers to, nil when it is not declared or is not a use. The qualifier of
+// Enum.Variant refers to the enum and the variant to its own declaration
+func (res *Resolver) Use(ident *ast.Identifier) *Symbol {
+	return res.uses[ident]
+}
+
+// Declaration a type name refers to, nil when it is not declared
+func (res *Resolver) TypeUse(typ *ast.NamedType) *Symbol {
+	return res.types[typ]
+}
+
+// Open a scope for the node, the locals declared by stmts are noted so an early use can be told apart from an undefined name
+func (res *Resolver) openScope(kind ScopeKind, node ast.Node, stmts ...ast.Statement) *Scope {
+	res.scope = newScope(kind, node, res.scope)
+	res.scopes[node] = res.scope
+
+	for _, stmt := range stmts {
+		switch decl := stmt.(type) {
+		case *ast.LetStatement:
+			res.addPending(decl.Name)
+		case *ast.ConstStatement:
+			res.addPending(decl.Name)
+		}
+	}
+
+	return res.scope
+}
+
+func (res *Resolver) addPending(ident *ast.Identifier) {
+	if _, ok := res.scope.pending[ident.Value]; !ok {
+		res.scope.pending[ident.Value] = ident
+	}
+}
+
+// Close the innermost scope
+func (res *Resolver) closeScope() {
+	res.scope = res.scope.Parent
+}
+
+// Add the names a statement declares to the innermost scope
+func (res *Resolver) declareStatement(stmt ast.Statement) {
+	switch decl := stmt.(type) {
+	case *ast.LetStatement:
+		res.declare(decl.Name, Var, decl)
+	case *ast.ConstStatement:
+		res.declare(decl.Name, Const, decl)
+	case *ast.FunctionDecl:
+		res.declare(decl.Name, Func, decl)
+	case *ast.StructDecl:
+		res.declareType(decl.Name, Struct, decl)
+	case *ast.UnionDecl:
+		res.declareType(decl.Name, Union, decl)
+	case *ast.EnumDecl:
+		res.declareType(decl.Name, Enum, decl)
+		for _, v := range decl.Variants {
+			res.variants[v] = res.declare(v.Name, Variant, v)
+		}
+	}
+}
+
+// Add a value to the innermost scope and return its symbol, a name already declared there is an error
+// and the symbol is only kept for an enum to name its variant
+func (res *Resolver) declare(ident *ast.Identifier, kind SymbolKind, decl ast.Node) *Symbol {
+	delete(res.scope.pending, ident.Value)
+
+	sym := &Symbol{Name: ident.Value, Kind: kind, Ident: ident, Decl: decl, Scope: res.scope}
+
+	if other, ok := res.scope.Symbols[ident.Value]; ok {
+		// Enums may share variant names, the variant can still be named through its enum. The checker
+		// reports a bare use that is ambiguous and a variant named twice in one enum is reported with
+		// the other members
+		if kind == Variant && other.Kind == Variant {
+			return sym
+		}
+
+		res.duplicate(ident, other)
+		return sym
+	}
+
+	res.scope.Symbols[ident.Value] = sym
+	return sym
+}
+
+// Add a type to the innermost scope
+func (res *Resolver) declareType(ident *ast.Identifier, kind SymbolKind, decl ast.Node) {
+	if other, ok := res.scope.Types[ident.Value]; ok {
+		res.duplicate(ident, other)
+		return
+	}
+
+	res.scope.Types[ident.Value] = &Symbol{Name: ident.Value, Kind: kind, Ident: ident, Decl: decl, Scope: res.scope}
+}
+
+func (res *Resolver) duplicate(ident *ast.Identifier, other *Symbol) {
+	res.diags = append(res.diags, diag.Errorf(diag.DuplicateDecl, diag.TokenSpan(ident.Token),
+		"%s is already declared in this scope", ident.Value).
+		WithLabel(diag.TokenSpan(other.Ident.Token), "%s %s first declared here", other.Kind, other.Name))
+}
+
+// Report the fields of a struct or union named twice
+func (res *Resolver) checkFields(owner *ast.Identifier, fields []*ast.Field) {
+	names := []*ast.Identifier{}
+	for _, f := range fields {
+		names = append(names, f.Name)
+	}
+	res.checkMembers(owner, "field", names)
+}
+
+// Report the fields or variants of a declaration that reuse the name of an earlier one
+func (res *Resolver) checkMembers(owner *ast.Identifier, kind string, names []*ast.Identifier) {
+	seen := map[string]*ast.Identifier{}
+
+	for _, ident := range names {
+		first, ok := seen[ident.Value]
+		if !ok {
+			seen[ident.Value] = ident
+			continue
+		}
+
+		res.diags = append(res.diags, diag.Errorf(diag.DuplicateMember, diag.TokenSpan(ident.Token),
+			"%s %s is already declared in %s", kind, ident.Value, owner.Value).
+			WithLabel(diag.TokenSpan(first.Token), "%s %s first declared here", kind, first.Value))
+	}
+}
+
+// Node being walked and the scope open when it was reached, restored once its children are done
+type frame struct {
+	node  ast.Node
+	scope *Scope
+}
+
+// Called by ast.Walk for each node, a function, block or loop body opens a scope that is
+// closed by the Visit(nil) after its children
+func (res *Resolver) Visit(node ast.Node) ast.Visitor {
+	if node == nil {
+		top := res.stack[len(res.stack)-1]
+		res.stack = res.stack[:len(res.stack)-1]
+		res.scope = top.scope
+		return nil
+	}
+
+	// Declarations at the top of the program were added before walking
+	outer, local := res.scope, res.scope != res.module
+	parent := res.parent()
+
+	switch n := node.(type) {
+	case *ast.LetStatement:
+		// The name comes into scope after its value, so let x = x does not see itself
+		res.walk(n.Type, n.Value)
+		if local {
+			res.declareStatement(n)
+		}
+		return nil
+	case *ast.ConstStatement:
+		res.walk(n.Type, n.Value)
+		if local {
+			res.declareStatement(n)
+		}
+		return nil
+	case *ast.Param:
+		res.walk(n.Type)
+		res.declare(n.Name, Param, n)
+		return nil
+	case *ast.BreakStatement, *ast.ContinueStatement:
+		// Labels name loops, not values
+		return nil
+	case *ast.FunctionDecl:
+		if local {
+			res.declareStatement(n)
+		}
+		// Parameters share the scope of the body
+		res.openScope(Function, n, n.Body.Statements...)
+	case *ast.StructDecl:
+		if local {
+			res.declareStatement(n)
+		}
+		res.checkFields(n.Name, n.Fields)
+	case *ast.UnionDecl:
+		if local {
+			res.declareStatement(n)
+		}
+		res.checkFields(n.Name, n.Fields)
+	case *ast.EnumDecl:
+		if local {
+			res.declareStatement(n)
+		}
+
+		names := []*ast.Identifier{}
+		for _, v := range n.Variants {
+			names = append(names, v.Name)
+		}
+		res.checkMembers(n.Name, "variant", names)
+	case *ast.BlockStatement:
+		switch p := parent.(type) {
+		case *ast.FunctionDecl:
+			// Opened with the parameters
+		case *ast.ForStatement:
+			// Walked after the iterable, which does not see the loop variable
+			res.openScope(Loop, p, n.Statements...)
+			res.declare(p.Var, Var, p)
+		case *ast.LoopStatement, *ast.WhileStatement:
+			res.openScope(Loop, p, n.Statements...)
+		default:
+			res.openScope(Block, n, n.Statements...)
+		}
+		res.scopes[n] = res.scope
+	case *ast.Identifier:
+		if !isName(parent, n) {
+			res.resolveIdentifier(n)
+		}
+	case *ast.NamedType:
+		res.resolveType(n)
+	case *ast.FieldExpression:
+		if res.resolveQualified(n) {
+			return nil
+		}
+	}
+
+	res.stack = append(res.stack, frame{node: node, scope: outer})
+	return res
+}
+
+// Walk each node that is set
+func (res *Resolver) walk(nodes ...ast.Node) {
+	for _, node := range nodes {
+		if node != nil {
+			ast.Walk(res, node)
+		}
+	}
+}
+
+// Node whose children are being walked, nil at the top of the program
+func (res *Resolver) parent() ast.Node {
+	if len(res.stack) == 0 {
+		return nil
+	}
+	return res.stack[len(res.stack)-1].node
+}
+
+// Report whether ident names something declared by parent, or a field or label, rather than using a value
+func isName(parent ast.Node, ident *ast.Identifier) bool {
+	switch node := parent.(type) {
+	case *ast.FunctionDecl:
+		return ident == node.Name
+	case *ast.StructDecl, *ast.UnionDecl, *ast.EnumDecl, *ast.Field:
+		// The only identifier these hold directly is their name
+		return true
+	case *ast.EnumVariant:
+		return ident == node.Name
+	case *ast.Attribute:
+		return ident == node.Name
+	case *ast.FieldExpression:
+		// The field is named by the type of the left side, not by a scope
+		return ident == node.Field
+	case *ast.ForStatement:
+		return ident == node.Var || ident == node.Label
+	case *ast.LoopStatement:
+		return ident == node.Label
+	case *ast.WhileStatement:
+		return ident == node.Label
+	}
+	return false
+}
+
+// Bind a name to its declaration, or report why it has none
+func (res *Resolver) resolveIdentifier(ident *ast.Identifier) {
+	if sym, ok := res.scope.Lookup(ident.Value); ok {
+		res.uses[ident] = sym
+		return
+	}
+
+	if decl, ok := res.scope.lookupPending(ident.Value); ok {
+		res.diags = append(res.diags, diag.Errorf(diag.UseBeforeDecl, diag.TokenSpan(ident.Token),
+			"%s is used before its declaration", ident.Value).
+			WithLabel(diag.TokenSpan(decl.Token), "%s declared here", ident.Value))
+		return
+	}
+
+	name, ok := res.suggest(ident.Value, false)
+	res.undefined(ident.Token, name, ok, "undefined name %s", ident.Value)
+}
+
+// Bind a type name to its declaration
+func (res *Resolver) resolveType(typ *ast.NamedType) {
+	if sym, ok := res.scope.LookupType(typ.Name); ok {
+		res.types[typ] = sym
+		return
+	}
+
+	name, ok := res.suggest(typ.Name, true)
+	res.undefined(typ.Token, name, ok, "undefined type %s", typ.Name)
+}
+
+// Bind Enum.Variant, the qualifier naming a type rather than a value, ok is false for any other field access
+func (res *Resolver) resolveQualified(fe *ast.FieldExpression) bool {
+	ident, ok := fe.Left.(*ast.Identifier)
+	if !ok {
+		return false
+	}
+
+	// A value of the same name hides the type
+	if _, ok := res.scope.Lookup(ident.Value); ok {
+		return false
+	}
+
+	typ, ok := res.scope.LookupType(ident.Value)
+	if !ok {
+		return false
+	}
+
+	res.uses[ident] = typ
+
+	decl, ok := typ.Decl.(*ast.EnumDecl)
+	if !ok {
+		return true
+	}
+
+	names := []string{}
+	for _, v := range decl.Variants {
+		if v.Name.Value == fe.Field.Value {
+			res.uses[fe.Field] = res.variants[v]
+			return true
+		}
+		names = append(names, v.Name.Value)
+	}
+
+	name, ok := closest(fe.Field.Value, names)
+	res.undefined(fe.Field.Token, name, ok, "enum %s has no variant %s", ident.Value, fe.Field.Value)
+	return true
+}
+
+// Report a name with no declaration, suggesting the closest declared one when ok
+func (res *Resolver) undefined(tok token.Token, suggestion string, ok bool, format string, args ...interface{}) {
+	d := diag.Errorf(diag.UndefinedName, diag.TokenSpan(tok), format, args...)
+
+	if ok {
+		d = d.WithFix(diag.TokenSpan(tok), suggestion, fmt.Sprintf("did you mean %s?", suggestion))
+	}
+
+	res.diags = append(res.diags, d)
+}
+
+// Closest name in scope to one that is not declared, among the types instead of the values when types is set
+func (res *Resolver) suggest(name string, types bool) (string, bool) {
+	candidates := []string{}
+
+	// Inner scopes first so a tie goes to the nearest name, sorted so it goes the same way on every run
+	for s := res.scope; s != nil; s = s.Parent {
+		table := s.Symbols
+		if types {
+			table = s.Types
+		}
+
+		names := []string{}
+		for other := range table {
+			names = append(names, other)
+		}
+		sort.Strings(names)
+
+		candidates = append(candidates, names...)
+	}
+
+	return closest(name, candidates)
+}
+
+// Candidate nearest to name, the first one wins a tie and ok is false when none is near enough
+func closest(name string, candidates []string) (string, bool) {
+	// A name can be off by about a third of its characters, and always by one
+	limit := (len(name) + 2) / 3
+
+	best, bestDist := "", limit+1
+
+	for _, other := range candidates {
+		if dist := distance(name, other); dist < bestDist {
+			best, bestDist = other, dist
+		}
+	}
+
+	return best, best != ""
+}
+
+// Levenshtein distance, the number of single character edits turning a into b
+func distance(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+func min3(a int, b int, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,250 @@
+package resolver
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Urvirith/bearlang/src/ast"
+	"github.com/Urvirith/bearlang/src/diag"
+	"github.com/Urvirith/bearlang/src/lexer"
+	"github.com/Urvirith/bearlang/src/parser"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	psr := parser.New(lexer.New(input))
+	prg := psr.ParseProgram()
+
+	if len(psr.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", psr.Errors())
+	}
+
+	return prg
+}
+
+func TestResolvePass(t *testing.T) {
+	tests := []string{
+		"const B: u32 = A + 1; const A: u32 = 1;",
+		"fn f() -> u32 { return g(); } fn g() -> u32 { return 1; }",
+		"fn f(a: u32) { let b = a; if b { let a = b; a; } a; }",
+		"fn f(p: Point*) { let q: Point = *p; q.x; } struct Point { x: u8 }",
+		"enum Mode { Idle, Run } enum Other { Idle } fn f(m: Mode) { match m { Idle => 1, Run => 2 } }",
+		"fn f() { for i: u8 in 0..10 { i; } let i = 1; }",
+		"const LEN: u32 = 4; @align(LEN) struct S { data: [LEN]u8 }",
+		"fn f() { let x = 1; outer: loop { let y = x; while y < 2 { y += 1; } break outer; } }",
+		"const B: u8 = 1; enum E: u8 { A = B } fn f(a: u8) { (a); return; }",
+		"enum Mode { Input } fn f() { let m = Mode.Input; }",
+		"enum A { X, Y } enum B { X } fn f() { let x = B.X; let y = A.Y; }",
+	}
+
+	for _, input := range tests {
+		if errors := New(parse(t, input)).Resolve(); len(errors) != 0 {
+			t.Errorf("%q: unexpected errors %q", input, errors)
+		}
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn f() { y; }", "1:10: undefined name y"},
+		{"const A: u32 = 1; const A: u32 = 2;", "1:25: A is already declared in this scope"},
+		{"fn f() {} const f: u32 = 1;", "1:17: f is already declared in this scope"},
+		{"fn f(a: u8, a: u8) {}", "1:13: a is already declared in this scope"},
+		{"fn f(a: u8) { let a = 1; }", "1:19: a is already declared in this scope"},
+		{"struct S { x: u8 } union S { y: u8 }", "1:26: S is already declared in this scope"},
+		{"fn f() { x; let x = 1; }", "1:10: x is used before its declaration"},
+		{"fn f() { if true { x; } let x = 1; }", "1:20: x is used before its declaration"},
+		{"fn f() { let x = x; }", "1:18: x is used before its declaration"},
+		{"fn f() { for i: u8 in 0..i {} }", "1:26: undefined name i"},
+		{"fn f() { if true { let x = 1; } x; }", "1:33: undefined name x"},
+		{"fn f() { match 1 { 0 => { let z = 1; } default => z } }", "1:51: undefined name z"},
+		{"enum E { A = C }", "1:14: undefined name C"},
+		{"fn f(p: Pointt) {} struct Point { x: u8 }", "1:9: undefined type Pointt"},
+		{"fn f() { let x: Mising = 1; }", "1:17: undefined type Mising"},
+		{"enum Mode { Input } fn f() { let m = Mode.Inptu; }", "1:43: enum Mode has no variant Inptu"},
+		{"fn f() { let m = Mode.Input; }", "1:18: undefined name Mode"},
+		{"struct S { a: u8, a: u16 }", "1:19: field a is already declared in S"},
+		{"fn f() { union U { a: u8, b: u8, a: u16 } }", "1:34: field a is already declared in U"},
+		{"enum E { A, B, A }", "1:16: variant A is already declared in E"},
+		{"fn f() { let x: [(N)]u8 = 0; }", "1:19: undefined name N"},
+	}
+
+	for _, tt := range tests {
+		errors := New(parse(t, tt.input)).Resolve()
+
+		if len(errors) != 1 || errors[0] != tt.expected {
+			t.Errorf("%q: expected error %q. got=%q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string // Suggested name, "" for none
+	}{
+		{"const PORTC_PIN7: u32 = 7; const LED: u32 = PORTC_PIN8;", "PORTC_PIN7"},
+		{"fn f(count: u32) { cuont; }", "count"},
+		{"fn f(n: u32) { i; }", "n"},
+		{"fn f(count: u32) { total; }", ""},
+		{"fn f() { let value = 1; if true { valeu; } }", "value"},
+		{"struct Point { x: u8 } fn f(p: Pointt*) {}", "Point"},
+		{"enum Mode { Input, Output } fn f() { Mode.Outptu; }", "Output"},
+	}
+
+	for _, tt := range tests {
+		res := New(parse(t, tt.input))
+		res.Resolve()
+
+		diags := res.Diagnostics()
+		if len(diags) != 1 || diags[0].Code != diag.UndefinedName {
+			t.Fatalf("%q: expected one undefined name. got=%q", tt.input, diags.Strings())
+		}
+
+		switch {
+		case tt.expected == "" && diags[0].Fix != nil:
+			t.Errorf("%q: expected no suggestion. got=%q", tt.input, diags[0].Fix.Replacement)
+		case tt.expected != "" && (diags[0].Fix == nil || diags[0].Fix.Replacement != tt.expected):
+			t.Errorf("%q: expected suggestion %q. got=%+v", tt.input, tt.expected, diags[0].Fix)
+		case tt.expected != "" && diags[0].Fix.Message != "did you mean "+tt.expected+"?":
+			t.Errorf("%q: wrong help %q", tt.input, diags[0].Fix.Message)
+		}
+	}
+}
+
+func TestDiagnosticLabels(t *testing.T) {
+	res := New(parse(t, "fn f() {\n    x;\n    let x = 1;\n    let x = 2;\n}"))
+	res.Resolve()
+
+	diags := res.Diagnostics()
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 diagnostics. got=%q", diags.Strings())
+	}
+
+	tests := []struct {
+		code  diag.Code
+		label string
+		line  int
+	}{
+		{diag.UseBeforeDecl, "x declared here", 3},
+		{diag.DuplicateDecl, "variable x first declared here", 3},
+	}
+
+	for i, tt := range tests {
+		d := diags[i]
+		if d.Code != tt.code {
+			t.Errorf("tests[%d] - wrong code. expected=%s, got=%s", i, tt.code, d.Code)
+		}
+
+		if len(d.Labels) != 1 || d.Labels[0].Message != tt.label || d.Labels[0].Span.Start.Line != tt.line {
+			t.Errorf("tests[%d] - wrong label. expected=%q on line %d, got=%+v", i, tt.label, tt.line, d.Labels)
+		}
+	}
+}
+
+func TestBindings(t *testing.T) {
+	prg := parse(t, "const A: u32 = 1; fn f(a: u32) -> u32 { let b = a + A; if true { let a = b; return a; } }")
+	res := New(prg)
+
+	if errors := res.Resolve(); len(errors) != 0 {
+		t.Fatalf("unexpected errors %q", errors)
+	}
+
+	fn := prg.Statements[1].(*ast.FunctionDecl)
+	let := fn.Body.Statements[0].(*ast.LetStatement)
+	inner := fn.Body.Statements[1].(*ast.IfStatement).Consequence
+	shadow := inner.Statements[0].(*ast.LetStatement)
+	ret := inner.Statements[1].(*ast.ReturnStatement)
+	sum := let.Value.(*ast.InfixExpression)
+
+	tests := []struct {
+		use  ast.Expression
+		kind SymbolKind
+		decl ast.Node
+	}{
+		{sum.Left, Param, fn.Params[0]},
+		{sum.Right, Const, prg.Statements[0]},
+		{shadow.Value, Var, let},
+		{ret.Value, Var, shadow},
+	}
+
+	for i, tt := range tests {
+		sym := res.Use(tt.use.(*ast.Identifier))
+		if sym == nil {
+			t.Fatalf("tests[%d] - %s is not bound", i, tt.use)
+		}
+
+		if sym.Kind != tt.kind || sym.Decl != tt.decl {
+			t.Errorf("tests[%d] - %s bound to the wrong declaration. got=%s %s", i, tt.use, sym.Kind, sym.Decl)
+		}
+	}
+
+	// Parameters and the body share a scope, the inner block opens its own
+	scope := res.ScopeOf(fn)
+	if scope == nil || scope.Kind != Function || scope.Parent != res.Module() || res.ScopeOf(fn.Body) != scope {
+		t.Fatalf("wrong function scope %+v", scope)
+	}
+
+	if block := res.ScopeOf(inner); block == nil || block.Kind != Block || block.Parent != scope {
+		t.Errorf("wrong block scope %+v", block)
+	}
+
+	if len(scope.Symbols) != 2 || scope.Symbols["a"].Kind != Param || scope.Symbols["b"].Kind != Var {
+		t.Errorf("wrong function symbols %v", scope.Symbols)
+	}
+}
+
+func TestResolveMain(t *testing.T) {
+	src, err := os.ReadFile("../../test/main.bl")
+	if err != nil {
+		t.Fatalf("reading test/main.bl: %s", err)
+	}
+
+	res := New(parse(t, string(src)))
+	res.Resolve()
+
+	// The loop counts with n but tests i
+	expected := []string{
+		"62:16: undefined name i",
+		"64:20: undefined name i",
+		"66:20: undefined name i",
+		"68:20: undefined name i",
+	}
+
+	diags := res.Diagnostics()
+	if len(diags) != len(expected) {
+		t.Fatalf("wrong number of diagnostics. expected=%d, got=%q", len(expected), diags.Strings())
+	}
+
+	for i, d := range diags {
+		if d.String() != expected[i] {
+			t.Errorf("diags[%d] - expected %q. got=%q", i, expected[i], d.String())
+		}
+
+		if d.Fix == nil || d.Fix.Replacement != "n" {
+			t.Errorf("diags[%d] - expected a suggestion of n. got=%+v", i, d.Fix)
+		}
+	}
+}
+
+func TestQualifiedBindings(t *testing.T) {
+	prg := parse(t, "enum A { X } enum B { X } fn f() { B.X; }")
+	res := New(prg)
+
+	if errors := res.Resolve(); len(errors) != 0 {
+		t.Fatalf("unexpected errors %q", errors)
+	}
+
+	enum := prg.Statements[1].(*ast.EnumDecl)
+	fe := prg.Statements[2].(*ast.FunctionDecl).Body.Statements[0].(*ast.ExpressionStatment).Expression.(*ast.FieldExpression)
+
+	if sym := res.Use(fe.Left.(*ast.Identifier)); sym == nil || sym.Kind != Enum || sym.Decl != enum {
+		t.Errorf("qualifier bound to the wrong declaration. got=%+v", sym)
+	}
+
+	if sym := res.Use(fe.Field); sym == nil || sym.Kind != Variant || sym.Decl != enum.Variants[0] {
+		t.Errorf("variant bound to the wrong declaration. got=%+v", sym)
+	}
+}
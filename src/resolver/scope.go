@@ -0,0 +1,111 @@
+package resolver
+
+import (
+	"github.com/Urvirith/bearlang/src/ast"
+)
+
+// Kind of a scope, the module holds everything declared at the top of the program
+type ScopeKind int
+
+const (
+	Module ScopeKind = iota
+	Function
+	Block
+	Loop
+)
+
+var scopeKinds = [...]string{Module: "module", Function: "function", Block: "block", Loop: "loop"}
+
+func (kind ScopeKind) String() string {
+	return scopeKinds[kind]
+}
+
+// Kind of the declaration a symbol names
+type SymbolKind int
+
+const (
+	Const SymbolKind = iota
+	Var
+	Param
+	Func
+	Struct
+	Union
+	Enum
+	Variant
+)
+
+var symbolKinds = [...]string{
+	Const: "constant", Var: "variable", Param: "parameter", Func: "function",
+	Struct: "struct", Union: "union", Enum: "enum", Variant: "enum variant",
+}
+
+func (kind SymbolKind) String() string {
+	return symbolKinds[kind]
+}
+
+// A declared name
+type Symbol struct {
+	Name  string
+	Kind  SymbolKind
+	Ident *ast.Identifier // Name at the declaration
+	Decl  ast.Node        // Declaring node, *ast.LetStatement, *ast.Param, *ast.EnumVariant etc
+	Scope *Scope          // Scope the name is declared in
+}
+
+// Names declared by a program, function, block or loop
+type Scope struct {
+	Kind     ScopeKind
+	Node     ast.Node // *ast.Program, *ast.FunctionDecl, *ast.BlockStatement or the loop statement
+	Parent   *Scope   // nil for the module
+	Children []*Scope
+	Symbols  map[string]*Symbol // Values, constants, variables, functions and enum variants
+	Types    map[string]*Symbol // Structs, unions and enums, type names do not clash with values
+	pending  map[string]*ast.Identifier
+}
+
+func newScope(kind ScopeKind, node ast.Node, parent *Scope) *Scope {
+	scope := &Scope{
+		Kind:    kind,
+		Node:    node,
+		Parent:  parent,
+		Symbols: make(map[string]*Symbol),
+		Types:   make(map[string]*Symbol),
+		pending: make(map[string]*ast.Identifier),
+	}
+
+	if parent != nil {
+		parent.Children = append(parent.Children, scope)
+	}
+
+	return scope
+}
+
+// Find a value by name, innermost scope first
+func (scope *Scope) Lookup(name string) (*Symbol, bool) {
+	for s := scope; s != nil; s = s.Parent {
+		if sym, ok := s.Symbols[name]; ok {
+			return sym, true
+		}
+	}
+	return nil, false
+}
+
+// Find a type by name, innermost scope first
+func (scope *Scope) LookupType(name string) (*Symbol, bool) {
+	for s := scope; s != nil; s = s.Parent {
+		if sym, ok := s.Types[name]; ok {
+			return sym, true
+		}
+	}
+	return nil, false
+}
+
+// Find a local declared further down one of the enclosing scopes
+func (scope *Scope) lookupPending(name string) (*ast.Identifier, bool) {
+	for s := scope; s != nil; s = s.Parent {
+		if ident, ok := s.pending[name]; ok {
+			return ident, true
+		}
+	}
+	return nil, false
+}
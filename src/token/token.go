@@ -1,45 +1,87 @@
 package token
 
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
 type TokenType string
 
 type Token struct {
 	Type    TokenType
 	Literal string
+	Pos     Position // Position of the first character of the token
+	End     Position // Position immediately after the last character of the token
+}
+
+// Position of a character within a source file
+type Position struct {
+	Filename string // Name of the source file, may be empty
+	Offset   int    // Byte offset, starting at 0
+	Line     int    // Line number, starting at 1
+	Column   int    // Column number in bytes, starting at 1
+}
+
+// A position is valid if it has a line number
+func (pos Position) IsValid() bool {
+	return pos.Line > 0
+}
+
+// Format the position as file:line:column, line:column or - if not valid
+func (pos Position) String() string {
+	if !pos.IsValid() {
+		if pos.Filename != "" {
+			return pos.Filename
+		}
+		return "-"
+	}
+
+	if pos.Filename != "" {
+		return fmt.Sprintf("%s:%d:%d", pos.Filename, pos.Line, pos.Column)
+	}
+
+	return fmt.Sprintf("%d:%d", pos.Line, pos.Column)
 }
 
 var keywords = map[string]TokenType{
-	"fn":      FUNCTION,
-	"let":     LET,
-	"vol":     VOLITILE,
-	"struct":  STRUCT,
-	"enum":    ENUM,
-	"union":   UNION,
-	"const":   CONST,
-	"return":  RETURN,
-	"import":  IMPORT,
-	"if":      IF,
-	"elif":    ELIF,
-	"else":    ELSE,
-	"match":   MATCH,
-	"default": DEFAULT,
-	"for":     FOR,
-	"loop":    LOOP,
-	"while":   WHILE,
-	"true":    TRUE,
-	"false":   FALSE,
-	"i8":      I8,
-	"i16":     I16,
-	"i32":     I32,
-	"i64":     I64,
-	"i128":    I128,
-	"u8":      U8,
-	"u16":     U16,
-	"u32":     U32,
-	"u64":     U64,
-	"u128":    U128,
-	"f32":     F32,
-	"f64":     F64,
-	"bool":    BOOL,
+	"fn":       FUNCTION,
+	"ext":      EXTERN,
+	"let":      LET,
+	"vol":      VOLITILE,
+	"struct":   STRUCT,
+	"enum":     ENUM,
+	"union":    UNION,
+	"const":    CONST,
+	"return":   RETURN,
+	"import":   IMPORT,
+	"if":       IF,
+	"elif":     ELIF,
+	"else":     ELSE,
+	"match":    MATCH,
+	"default":  DEFAULT,
+	"for":      FOR,
+	"loop":     LOOP,
+	"while":    WHILE,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"true":     TRUE,
+	"false":    FALSE,
+	"i8":       I8,
+	"i16":      I16,
+	"i32":      I32,
+	"i64":      I64,
+	"i128":     I128,
+	"u8":       U8,
+	"u16":      U16,
+	"u32":      U32,
+	"u64":      U64,
+	"u128":     U128,
+	"f32":      F32,
+	"f64":      F64,
+	"bool":     BOOL,
 }
 
 // Constants For The Types Of Tokens
@@ -50,7 +92,10 @@ const (
 
 	// Identifiers & Literals
 	IDENTIFIER = "IDENTIFIER" // add, x, y, etc...
-	INT        = "INT"        // Place Holder For Any Number
+	INT        = "INT"        // Integer Number, Decimal Or 0x, 0b, 0o Prefixed
+	FLOAT      = "FLOAT"      // Floating Point Number With Decimal Point Or Exponent
+	CHAR       = "CHAR"       // Single Byte Character 'A', Typed As u8
+	STRING     = "STRING"     // Byte String "hello\n", Typed As [N]u8
 
 	// Number Declarations
 	I8   = "I8"   // Signed Integer 8 Bit
@@ -78,18 +123,23 @@ const (
 	DEC        = "--"
 	ADD_ASSIGN = "+="
 	SUB_ASSIGN = "-="
+	MUL_ASSIGN = "*="
+	DIV_ASSIGN = "/="
+	MOD_ASSIGN = "%="
 
 	// Bitwise Operators
-	OR         = "|"
-	OR_ASSIGN  = "|="
-	AND        = "&"
-	AND_ASSIGN = "&="
-	XOR        = "^"
-	XOR_ASSIGN = "^="
-	NOT        = "!"
-	COMP       = "~"
-	LSHF       = "<<"
-	RSHF       = ">>"
+	OR          = "|"
+	OR_ASSIGN   = "|="
+	AND         = "&"
+	AND_ASSIGN  = "&="
+	XOR         = "^"
+	XOR_ASSIGN  = "^="
+	NOT         = "!"
+	COMP        = "~"
+	LSHF        = "<<"
+	LSHF_ASSIGN = "<<="
+	RSHF        = ">>"
+	RSHF_ASSIGN = ">>="
 
 	// Comparators
 	EQU  = "=="
@@ -111,10 +161,18 @@ const (
 	COMMA  = ","
 	COLON  = ":"
 	SCOLON = ";"
+	ARROW  = "->"
+	AT     = "@" // Starts A Layout Attribute, @packed
+	DOT    = "." // Field Access, gpio.moder
+
+	// Ranges
+	RANGE      = ".."  // Exclusive Range
+	RANGE_INCL = "..=" // Inclusive Range
 
 	// Keywords
 	IMPORT   = "IMPORT"   // Import
 	FUNCTION = "FUNCTION" // Function
+	EXTERN   = "EXTERN"   // External Linkage
 	LET      = "LET"      // Let (Variable Declare)
 	VOLITILE = "VOLITILE" // Volitile
 	STRUCT   = "STRUCT"   // Structure
@@ -133,12 +191,83 @@ const (
 	FOR          = "FOR"
 	LOOP         = "LOOP"
 	WHILE        = "WHILE"
+	IN           = "IN"
+	BREAK        = "BREAK"
+	CONTINUE     = "CONTINUE"
 
 	// BINARY
 	TRUE  = "TRUE"
 	FALSE = "FALSE"
 )
 
+// Type suffixes allowed on number literals, 255u8 or 1.5f32
+var suffixes = []string{"i8", "i16", "i32", "i64", "i128", "u8", "u16", "u32", "u64", "u128", "f32", "f64"}
+
+// Split a number literal into its body and type suffix, the suffix is "" when not present
+func SplitSuffix(lit string) (string, string) {
+	base, _ := SplitInt(lit)
+
+	for _, sfx := range suffixes {
+		if len(lit) <= len(sfx) || !strings.HasSuffix(lit, sfx) {
+			continue
+		}
+
+		// f is a hex digit, so 0xFf32 has no suffix
+		if sfx[0] == 'f' && base != 10 {
+			continue
+		}
+
+		return lit[:len(lit)-len(sfx)], sfx
+	}
+
+	return lit, ""
+}
+
+// Bounds of an integer type named i8..i128 or u8..u128
+func IntRange(name string) (*big.Int, *big.Int, bool) {
+	if len(name) < 2 || (name[0] != 'i' && name[0] != 'u') {
+		return nil, nil, false
+	}
+
+	bits, err := strconv.Atoi(name[1:])
+	if err != nil {
+		return nil, nil, false
+	}
+
+	switch bits {
+	case 8, 16, 32, 64, 128:
+	default:
+		return nil, nil, false
+	}
+
+	one := big.NewInt(1)
+
+	if name[0] == 'u' {
+		max := new(big.Int).Sub(new(big.Int).Lsh(one, uint(bits)), one)
+		return new(big.Int), max, true
+	}
+
+	max := new(big.Int).Sub(new(big.Int).Lsh(one, uint(bits-1)), one)
+	min := new(big.Int).Neg(new(big.Int).Lsh(one, uint(bits-1)))
+	return min, max, true
+}
+
+// Split an integer literal into its base and digits, the 0x, 0b or 0o prefix is removed
+func SplitInt(lit string) (int, string) {
+	if len(lit) > 1 && lit[0] == '0' {
+		switch lit[1] {
+		case 'x', 'X':
+			return 16, lit[2:]
+		case 'b', 'B':
+			return 2, lit[2:]
+		case 'o', 'O':
+			return 8, lit[2:]
+		}
+	}
+
+	return 10, lit
+}
+
 func LookupID(id string) TokenType {
 	if tok, ok := keywords[id]; ok {
 		return tok